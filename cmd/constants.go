@@ -0,0 +1,12 @@
+package main
+
+var DEFAULT_ARTIFACT_DENY = ".exe,.dll,.so,.dylib,.a,.o,.class,.jar,.war,.zip,.gz,.tgz,.tar,.7z,.png,.jpg,.jpeg,.gif,.ico,.pdf,.mp4,.woff,.woff2,.ttf,.iso"
+var DEFAULT_VERIFY_GITHUB_URL = "https://api.github.com"
+var DEFAULT_VERIFY_SLACK_URL = "https://slack.com"
+var DEFAULT_VERIFY_AWS_URL = "https://sts.amazonaws.com"
+var DEFAULT_REGISTRY_NPM_URL = "https://registry.npmjs.org"
+var DEFAULT_REGISTRY_PYPI_URL = "https://pypi.org"
+var DEFAULT_REGISTRY_RUBYGEMS_URL = "https://rubygems.org"
+var DEFAULT_REGISTRY_PACKAGIST_URL = "https://repo.packagist.org"
+var DEFAULT_REGISTRY_GO_URL = "https://proxy.golang.org"
+var DEFAULT_REGISTRY_RDAP_URL = "https://rdap.org"
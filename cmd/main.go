@@ -8,17 +8,62 @@ import (
 
 func main() {
 	opts := workflow.Opts{
-		Organisation:      flag.String("org", "", "The organisation to scan"),
-		Owner:             flag.String("owner", "", "The owner of the repository"),
-		Repo:              flag.String("repo", "", "The name of the repository"),
-		WordlistVariables: flag.String("wv", "", "The wordlist of variable names"),
-		WordlistKeywords:  flag.String("wk", "", "The wordlist of keywords"),
-		ThreadsDownload:   flag.Int("td", 5, "Number of threads for download (be wary of GitHub API rate limits)"),
-		ThreadsSearch:     flag.Int("ts", 20, "Number of threads for search"),
-		IsDownload:        flag.Bool("download", false, "Run the log download from GitHub"),
-		IsSearch:          flag.Bool("search", false, "Run the search on existing logs in the current directory"),
-		IsOrgRepos:        flag.Bool("org-repos", false, "Run for organisation repos"),
-		IsOrgMembersRepos: flag.Bool("org-members", false, "Run for organisation members repos"),
+		Organisation:          flag.String("org", "", "The organisation to scan"),
+		Owner:                 flag.String("owner", "", "The owner of the repository"),
+		Repo:                  flag.String("repo", "", "The name of the repository"),
+		WordlistVariables:     flag.String("wv", "", "The wordlist of variable names"),
+		WordlistKeywords:      flag.String("wk", "", "The wordlist of keywords"),
+		RulesPaths:            flag.String("rules", "", "Comma separated yaml rule files"),
+		ThreadsDownload:       flag.Int("td", 5, "Number of threads for download (be wary of GitHub API rate limits)"),
+		ThreadsSearch:         flag.Int("ts", 20, "Number of threads for search"),
+		MinConfidence:         flag.Float64("min-confidence", 0, "Only report results with at least this confidence (0 to 1) of being a secret"),
+		IsSignatures:          flag.Bool("signatures", true, "Search for built-in secret signatures such as AWS keys and GitHub tokens"),
+		IsDecode:              flag.Bool("decode", true, "Decode base64, hex and url encoded text in logs and search it again"),
+		IsMaskLeaks:           flag.Bool("mask-leaks", true, "Find values masked with ::add-mask:: that were printed without the mask elsewhere in the run"),
+		IsEnvDumps:            flag.Bool("env-dumps", true, "Find steps that printed their environment or traced commands with set -x, and the variables they printed"),
+		Context:               flag.Int("context", 0, "Number of lines to show before and after each result"),
+		ContextBefore:         flag.Int("context-before", -1, "Number of lines to show before each result (defaults to -context)"),
+		ContextAfter:          flag.Int("context-after", -1, "Number of lines to show after each result (defaults to -context)"),
+		IsRedact:              flag.Bool("redact", false, "Redact secrets in results and the lines shown around them"),
+		PlaceholdersPath:      flag.String("placeholders", "", "A yaml file of extra placeholder values to leave out of variable assignment results"),
+		IsShowFiltered:        flag.Bool("show-filtered", false, "Show each variable assignment left out as a placeholder and why"),
+		IsVerify:              flag.Bool("verify", false, "Check whether secrets found by signatures are still live with their provider"),
+		VerifyGitHubUrl:       flag.String("verify-github-url", DEFAULT_VERIFY_GITHUB_URL, "The GitHub API url used to verify GitHub tokens and look up the owners of Go modules"),
+		VerifySlackUrl:        flag.String("verify-slack-url", DEFAULT_VERIFY_SLACK_URL, "The Slack url used to verify Slack tokens"),
+		VerifyAwsUrl:          flag.String("verify-aws-url", DEFAULT_VERIFY_AWS_URL, "The AWS STS url used to verify AWS keys"),
+		IsDependencyConfusion: flag.Bool("dependency-confusion", false, "Find missing dependencies in logs and check whether their names are unclaimed on the public registry"),
+		RegistryNpmUrl:        flag.String("registry-npm-url", DEFAULT_REGISTRY_NPM_URL, "The npm registry url used to check package names"),
+		RegistryPypiUrl:       flag.String("registry-pypi-url", DEFAULT_REGISTRY_PYPI_URL, "The PyPI url used to check package names"),
+		RegistryRubygemsUrl:   flag.String("registry-rubygems-url", DEFAULT_REGISTRY_RUBYGEMS_URL, "The RubyGems url used to check gem names"),
+		RegistryPackagistUrl:  flag.String("registry-packagist-url", DEFAULT_REGISTRY_PACKAGIST_URL, "The Packagist repository url used to check Composer package names"),
+		RegistryGoUrl:         flag.String("registry-go-url", DEFAULT_REGISTRY_GO_URL, "The Go module proxy url used to check module paths"),
+		RegistryRdapUrl:       flag.String("registry-rdap-url", DEFAULT_REGISTRY_RDAP_URL, "The RDAP url used to check whether the domain of a Go module path is registered"),
+		IsDownload:            flag.Bool("download", false, "Run the log download from GitHub"),
+		IsSearch:              flag.Bool("search", false, "Run the search on existing logs in the current directory"),
+		IsOrgRepos:            flag.Bool("org-repos", false, "Run for organisation repos"),
+		IsOrgMembersRepos:     flag.Bool("org-members", false, "Run for organisation members repos"),
+		IsJobLogs:             flag.Bool("job-logs", false, "Download each job log separately, keeping job and step names for results"),
+		IsArtifacts:           flag.Bool("artifacts", false, "Also download and search the artifacts of each run"),
+		ArtifactMaxSize:       flag.Int64("artifact-max-size", 100, "Skip artifacts larger than this many megabytes"),
+		ArtifactAllow:         flag.String("artifact-allow", "", "Comma separated file extensions to extract from artifacts (default all)"),
+		ArtifactDeny:          flag.String("artifact-deny", DEFAULT_ARTIFACT_DENY, "Comma separated file extensions to skip in artifacts"),
+		IsWorkflowFiles:       flag.Bool("workflow-files", false, "Also scan the workflow files of each repo for insecure patterns"),
+		Since:                 flag.String("since", "", "Only download runs created on or after this date (YYYY-MM-DD or RFC3339)"),
+		Until:                 flag.String("until", "", "Only download runs created on or before this date (YYYY-MM-DD or RFC3339)"),
+		Branch:                flag.String("branch", "", "Only download runs for this branch"),
+		Event:                 flag.String("event", "", "Only download runs triggered by this event (e.g. push, pull_request)"),
+		Status:                flag.String("status", "", "Only download runs with this status or conclusion (e.g. completed, failure)"),
+		Actor:                 flag.String("actor", "", "Only download runs triggered by this user"),
+		BaseUrl:               flag.String("base-url", "", "The API URL of a GitHub Enterprise Server instance (e.g. https://github.example.com/api/v3/)"),
+		UploadUrl:             flag.String("upload-url", "", "The upload URL of a GitHub Enterprise Server instance (defaults to -base-url)"),
+		CaBundlePath:          flag.String("ca-bundle", "", "A PEM file of extra CA certificates to trust, for GitHub Enterprise Server"),
+		TokenFilePath:         flag.String("token-file", "", "A file of GitHub tokens to rotate between, one per line (also read from GH_TOKENS)"),
+		AppId:                 flag.Int64("app-id", 0, "The GitHub App id to authenticate as (instead of GH_TOKEN)"),
+		AppInstallationId:     flag.Int64("app-installation", 0, "The installation id of the GitHub App"),
+		AppPrivateKeyPath:     flag.String("app-key", "", "The private key file of the GitHub App"),
+		BaselinePath:          flag.String("baseline", "", "The baseline file of known results to suppress"),
+		IsWriteBaseline:       flag.Bool("write-baseline", false, "Add the results of this scan to the -baseline file"),
+		AllowlistPath:         flag.String("allowlist", "", "The yaml allowlist of values, paths and repos to suppress"),
 	}
 	flag.Parse()
 	workflow.Run(opts)
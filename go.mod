@@ -6,4 +6,5 @@ require (
 	github.com/google/go-github/v37 v37.0.0
 	github.com/google/uuid v1.3.0
 	golang.org/x/oauth2 v0.0.0-20210628180205-a41e5a781914
+	gopkg.in/yaml.v3 v3.0.1
 )
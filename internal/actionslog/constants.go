@@ -0,0 +1,7 @@
+package actionslog
+
+var MAX_LINE_LENGTH = 1024 * 1024
+var BINARY_CHECK_LENGTH = 8000
+var LOG_FILE_EXTENSION = ".txt"
+var GROUP_COMMAND = "group"
+var END_GROUP_COMMAND = "endgroup"
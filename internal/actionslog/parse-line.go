@@ -0,0 +1,100 @@
+package actionslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bm402/gander/internal/logger"
+)
+
+var logCommandRegex = regexp.MustCompile(`^##\[([a-z-]+)\](.*)$`)
+var workflowCommandRegex = regexp.MustCompile(`^::([a-z-]+)(?: [^:]*)?::(.*)$`)
+
+// Line is one line of a log, with the timestamp the runner wrote at the start of it taken off
+type Line struct {
+	Filename  string
+	Number    int
+	Timestamp time.Time
+	Content   string
+	Command   string
+	Message   string
+	Groups    []string
+}
+
+// ParseLine splits a line into its timestamp and content, and works out the command if the content
+// is a ##[command] written by the runner or a ::command:: written by the workflow
+func ParseLine(text string) Line {
+	text = strings.TrimPrefix(text, "\ufeff")
+	line := Line{
+		Content: text,
+	}
+	parts := strings.SplitN(text, " ", 2)
+	if timestamp, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+		line.Timestamp = timestamp
+		line.Content = ""
+		if len(parts) > 1 {
+			line.Content = parts[1]
+		}
+	}
+
+	trimmed := strings.TrimSpace(line.Content)
+	if command := logCommandRegex.FindStringSubmatch(trimmed); command != nil {
+		line.Command = command[1]
+		line.Message = command[2]
+	} else if command := workflowCommandRegex.FindStringSubmatch(trimmed); command != nil {
+		line.Command = command[1]
+		line.Message = command[2]
+	}
+	return line
+}
+
+// ParseLogFile reads every line of a log, keeping track of the groups each line is nested in
+func ParseLogFile(filename string) ([]Line, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if head, _ := reader.Peek(BINARY_CHECK_LENGTH); bytes.IndexByte(head, 0) >= 0 {
+		return nil, errors.New("not a text file")
+	}
+
+	lines := []Line{}
+	groups := []string{}
+	for lineNumber := 1; ; lineNumber++ {
+		text, err := reader.ReadString('\n')
+		if len(text) > MAX_LINE_LENGTH {
+			logger.Print("gander", "", "parse-log", "Line", lineNumber, "of", filename, "is longer than", MAX_LINE_LENGTH,
+				"bytes, only the start of it is searched")
+			text = text[:MAX_LINE_LENGTH]
+		}
+		if len(text) > 0 {
+			line := ParseLine(strings.TrimRight(text, "\r\n"))
+			line.Filename = filename
+			line.Number = lineNumber
+
+			// the slice of groups is replaced rather than changed, so lines can share it
+			if line.Command == GROUP_COMMAND {
+				groups = append(append([]string{}, groups...), line.Message)
+			}
+			line.Groups = groups
+			if line.Command == END_GROUP_COMMAND && len(groups) > 0 {
+				groups = groups[:len(groups)-1]
+			}
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			return lines, nil
+		} else if err != nil {
+			return lines, err
+		}
+	}
+}
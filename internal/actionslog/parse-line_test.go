@@ -0,0 +1,101 @@
+package actionslog
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		text      string
+		timestamp string
+		content   string
+		command   string
+		message   string
+	}{
+		{"2021-08-01T12:00:00.1234567Z echo hello", "2021-08-01T12:00:00.1234567Z", "echo hello", "", ""},
+		{"\ufeff2021-08-01T12:00:00.0000000Z hello", "2021-08-01T12:00:00Z", "hello", "", ""},
+		{"2021-08-01T12:00:00.0000000Z", "2021-08-01T12:00:00Z", "", "", ""},
+		{"continued without a timestamp", "", "continued without a timestamp", "", ""},
+		{"2021-08-01 is not a timestamp", "", "2021-08-01 is not a timestamp", "", ""},
+		{"2021-08-01T12:00:00.0000000Z ##[group]Run make test", "2021-08-01T12:00:00Z", "##[group]Run make test", GROUP_COMMAND, "Run make test"},
+		{"2021-08-01T12:00:00.0000000Z ##[endgroup]", "2021-08-01T12:00:00Z", "##[endgroup]", END_GROUP_COMMAND, ""},
+		{"##[group]Without a timestamp", "", "##[group]Without a timestamp", GROUP_COMMAND, "Without a timestamp"},
+		{"2021-08-01T12:00:00.0000000Z   ##[error]Process completed with exit code 1.", "2021-08-01T12:00:00Z", "  ##[error]Process completed with exit code 1.", "error", "Process completed with exit code 1."},
+		{"2021-08-01T12:00:00.0000000Z ::add-mask::hunter2", "2021-08-01T12:00:00Z", "::add-mask::hunter2", "add-mask", "hunter2"},
+		{"2021-08-01T12:00:00.0000000Z ::warning file=app.js,line=1::Unused variable", "2021-08-01T12:00:00Z", "::warning file=app.js,line=1::Unused variable", "warning", "Unused variable"},
+		{"2021-08-01T12:00:00.0000000Z echo ##[group]not a command", "2021-08-01T12:00:00Z", "echo ##[group]not a command", "", ""},
+	}
+	for _, test := range tests {
+		line := ParseLine(test.text)
+		timestamp := ""
+		if !line.Timestamp.IsZero() {
+			timestamp = line.Timestamp.Format(time.RFC3339Nano)
+		}
+		if timestamp != test.timestamp || line.Content != test.content || line.Command != test.command || line.Message != test.message {
+			t.Errorf("expected %q to parse to %q %q %q %q, got %q %q %q %q", test.text, test.timestamp, test.content, test.command,
+				test.message, timestamp, line.Content, line.Command, line.Message)
+		}
+	}
+}
+
+func TestParseLogFileNestsGroups(t *testing.T) {
+	lines, err := ParseLogFile(filepath.Join("testdata", "run", "build", "2_Run tests.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		content string
+		groups  string
+	}{
+		{"##[group]Run make test", "Run make test"},
+		{"make test", "Run make test"},
+		{"##[endgroup]", "Run make test"},
+		{"##[group]Test results", "Test results"},
+		{"##[group]Unit tests", "Test results > Unit tests"},
+		{"ok  parser  0.01s", "Test results > Unit tests"},
+		{"continued without a timestamp", "Test results > Unit tests"},
+		{"##[endgroup]", "Test results > Unit tests"},
+		{"ok  integration  1.20s", "Test results"},
+		{"##[endgroup]", "Test results"},
+		{"##[error]Process completed with exit code 1.", ""},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %d", len(expected), len(lines))
+	}
+	for idx, line := range lines {
+		groups := strings.Join(line.Groups, " > ")
+		if line.Number != idx+1 || line.Content != expected[idx].content || groups != expected[idx].groups {
+			t.Errorf("expected line %d to be %q in %q, got line %d %q in %q", idx+1, expected[idx].content, expected[idx].groups,
+				line.Number, line.Content, groups)
+		}
+	}
+	if !lines[6].Timestamp.IsZero() {
+		t.Errorf("expected the line without a timestamp to have none, got %s", lines[6].Timestamp)
+	}
+}
+
+func TestParseRunFolder(t *testing.T) {
+	folderPath := filepath.Join("testdata", "run")
+	run, err := ParseRunFolder(folderPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the whole job log next to the folder of its steps is not read twice
+	if len(run.Jobs) != 1 || run.Jobs[0].Name != "build" {
+		t.Fatalf("expected only the build job, got %v", run.Jobs)
+	}
+	steps := []string{}
+	for _, step := range run.Jobs[0].Steps {
+		steps = append(steps, FormatStep(step))
+	}
+	if strings.Join(steps, ", ") != "1 Set up job, 2 Run tests" {
+		t.Errorf("expected the steps in order, got %v", steps)
+	}
+	if len(run.OtherFiles) != 1 || run.OtherFiles[0] != filepath.Join(folderPath, "notes.md") {
+		t.Errorf("expected notes.md to be the only other file, got %v", run.OtherFiles)
+	}
+}
@@ -0,0 +1,176 @@
+package actionslog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/bm402/gander/internal/metadata"
+)
+
+var logFilenameRegex = regexp.MustCompile(`^(\d+)_(.*)\.txt$`)
+
+// Run is a downloaded run folder split into the jobs and steps that wrote each line
+type Run struct {
+	FolderPath string
+	Jobs       []*Job
+	OtherFiles []string
+}
+
+type Job struct {
+	Name  string
+	Steps []*Step
+}
+
+type Step struct {
+	Number int64
+	Name   string
+	Lines  []Line
+}
+
+// ParseRunFolder reads the logs of a run. Run archives have a folder of step logs for each job, and
+// job logs downloaded separately have one file per job that is split into steps using the times
+// in the jobs file. Files that are not logs, such as artifacts, are listed in OtherFiles
+func ParseRunFolder(folderPath string) (Run, error) {
+	run := Run{
+		FolderPath: folderPath,
+	}
+	entries, err := ioutil.ReadDir(folderPath)
+	if err != nil {
+		return run, err
+	}
+	jobsMetadata, _ := metadata.ReadJobs(folderPath)
+
+	jobFolders := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			jobFolders[entry.Name()] = true
+		}
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(folderPath, entry.Name())
+		switch {
+		case entry.IsDir() && entry.Name() == metadata.ARTIFACTS_FOLDERNAME:
+			run.OtherFiles = append(run.OtherFiles, listFiles(path)...)
+		case entry.IsDir():
+			job, otherFiles := parseJobFolder(path)
+			run.Jobs = append(run.Jobs, job)
+			run.OtherFiles = append(run.OtherFiles, otherFiles...)
+		case isMetadataFile(entry.Name()):
+			continue
+		case logFilenameRegex.MatchString(entry.Name()):
+			name := logFilenameRegex.FindStringSubmatch(entry.Name())[2]
+			// run archives have the whole job log next to the folder of its steps
+			if jobFolders[name] {
+				continue
+			}
+			job, err := parseJobFile(path, jobsMetadata)
+			if err != nil {
+				run.OtherFiles = append(run.OtherFiles, path)
+				continue
+			}
+			run.Jobs = append(run.Jobs, job)
+		default:
+			run.OtherFiles = append(run.OtherFiles, path)
+		}
+	}
+	return run, nil
+}
+
+func parseJobFolder(folderPath string) (*Job, []string) {
+	job := &Job{
+		Name:  filepath.Base(folderPath),
+		Steps: []*Step{},
+	}
+	otherFiles := []string{}
+	for _, path := range listFiles(folderPath) {
+		parts := logFilenameRegex.FindStringSubmatch(filepath.Base(path))
+		if parts == nil || filepath.Dir(path) != folderPath {
+			otherFiles = append(otherFiles, path)
+			continue
+		}
+		lines, err := ParseLogFile(path)
+		if err != nil {
+			otherFiles = append(otherFiles, path)
+			continue
+		}
+		number, _ := strconv.ParseInt(parts[1], 10, 64)
+		job.Steps = append(job.Steps, &Step{
+			Number: number,
+			Name:   parts[2],
+			Lines:  lines,
+		})
+	}
+	sort.Slice(job.Steps, func(i, j int) bool {
+		return job.Steps[i].Number < job.Steps[j].Number
+	})
+	return job, otherFiles
+}
+
+func parseJobFile(path string, jobsMetadata []metadata.Job) (*Job, error) {
+	lines, err := ParseLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	jobMetadata, exists := metadata.GetJobByFilename(jobsMetadata, filepath.Base(path))
+	if !exists {
+		return &Job{
+			Name:  logFilenameRegex.FindStringSubmatch(filepath.Base(path))[2],
+			Steps: []*Step{{Lines: lines}},
+		}, nil
+	}
+
+	// lines without a timestamp stay with the step of the line before them
+	job := &Job{
+		Name:  jobMetadata.Name,
+		Steps: []*Step{},
+	}
+	current := &Step{}
+	for _, line := range lines {
+		if !line.Timestamp.IsZero() {
+			stepMetadata, _ := jobMetadata.GetStepAtTime(line.Timestamp)
+			if stepMetadata.Number != current.Number || stepMetadata.Name != current.Name {
+				if len(current.Lines) > 0 {
+					job.Steps = append(job.Steps, current)
+				}
+				current = &Step{
+					Number: stepMetadata.Number,
+					Name:   stepMetadata.Name,
+				}
+			}
+		}
+		current.Lines = append(current.Lines, line)
+	}
+	if len(current.Lines) > 0 {
+		job.Steps = append(job.Steps, current)
+	}
+	return job, nil
+}
+
+func listFiles(folderPath string) []string {
+	files := []string{}
+	filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
+
+// the files written next to the logs of each run are not logs themselves
+func isMetadataFile(name string) bool {
+	return name == metadata.ID_FILENAME || name == metadata.RUN_FILENAME || name == metadata.JOBS_FILENAME
+}
+
+// FormatStep describes a step the way it is shown in the actions ui, or is empty if the step is not known
+func FormatStep(step *Step) string {
+	if step.Name == "" {
+		return ""
+	}
+	return strconv.FormatInt(step.Number, 10) + " " + step.Name
+}
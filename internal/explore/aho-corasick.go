@@ -0,0 +1,90 @@
+package explore
+
+// ahoCorasick finds every occurrence of a set of literals in a single pass over the text. Literals
+// and text are compared with ascii letters lowercased
+type ahoCorasick struct {
+	transitions []map[byte]int
+	fail        []int
+	outputs     [][]int
+}
+
+func newAhoCorasick(literals []string) *ahoCorasick {
+	automaton := &ahoCorasick{
+		transitions: []map[byte]int{{}},
+		fail:        []int{0},
+		outputs:     [][]int{nil},
+	}
+
+	// build the trie of literals
+	for idx, literal := range literals {
+		node := 0
+		for i := 0; i < len(literal); i++ {
+			c := toLowerAscii(literal[i])
+			next, exists := automaton.transitions[node][c]
+			if !exists {
+				next = len(automaton.transitions)
+				automaton.transitions = append(automaton.transitions, map[byte]int{})
+				automaton.fail = append(automaton.fail, 0)
+				automaton.outputs = append(automaton.outputs, nil)
+				automaton.transitions[node][c] = next
+			}
+			node = next
+		}
+		automaton.outputs[node] = append(automaton.outputs[node], idx)
+	}
+
+	// breadth first to set the failure links, merging the outputs of each node's failure node
+	queue := []int{}
+	for _, child := range automaton.transitions[0] {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for c, child := range automaton.transitions[node] {
+			queue = append(queue, child)
+			fail := automaton.fail[node]
+			for {
+				if next, exists := automaton.transitions[fail][c]; exists && next != child {
+					automaton.fail[child] = next
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = automaton.fail[fail]
+			}
+			automaton.outputs[child] = append(automaton.outputs[child], automaton.outputs[automaton.fail[child]]...)
+		}
+	}
+
+	return automaton
+}
+
+// findAll calls found with the index of each literal that occurs in the text, possibly more than once
+func (a *ahoCorasick) findAll(text string, found func(idx int)) {
+	node := 0
+	for i := 0; i < len(text); i++ {
+		c := toLowerAscii(text[i])
+		for {
+			if next, exists := a.transitions[node][c]; exists {
+				node = next
+				break
+			}
+			if node == 0 {
+				break
+			}
+			node = a.fail[node]
+		}
+		for _, idx := range a.outputs[node] {
+			found(idx)
+		}
+	}
+}
+
+func toLowerAscii(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
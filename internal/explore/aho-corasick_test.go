@@ -0,0 +1,54 @@
+package explore
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestAhoCorasick(t *testing.T) {
+	tests := []struct {
+		literals []string
+		text     string
+		found    []int
+	}{
+		// overlapping literals
+		{[]string{"he", "she", "his", "hers"}, "ushers", []int{0, 1, 3}},
+		// literals that are suffixes of each other
+		{[]string{"abcd", "bcd", "cd", "d"}, "xabcdx", []int{0, 1, 2, 3}},
+		// a failed match falls back to a shorter prefix
+		{[]string{"aab", "ab"}, "aaab", []int{0, 1}},
+		// case is ignored
+		{[]string{"ghp_", "PASSWORD"}, "GHP_x password", []int{0, 1}},
+		// duplicate literals are both reported
+		{[]string{"key", "key"}, "a key", []int{0, 1}},
+		{[]string{"token"}, "tokes", []int{}},
+		{[]string{}, "anything", []int{}},
+	}
+	for _, test := range tests {
+		automaton := newAhoCorasick(test.literals)
+		foundSet := make(map[int]bool)
+		automaton.findAll(test.text, func(idx int) {
+			foundSet[idx] = true
+		})
+		found := []int{}
+		for idx := range foundSet {
+			found = append(found, idx)
+		}
+		sort.Ints(found)
+		if !reflect.DeepEqual(found, test.found) {
+			t.Errorf("expected %v in %q to find %v, got %v", test.literals, test.text, test.found, found)
+		}
+	}
+}
+
+func TestAhoCorasickReportsEveryOccurrence(t *testing.T) {
+	automaton := newAhoCorasick([]string{"aa"})
+	count := 0
+	automaton.findAll("aaaa", func(idx int) {
+		count++
+	})
+	if count != 3 {
+		t.Errorf("expected 3 occurrences of aa in aaaa, got %d", count)
+	}
+}
@@ -0,0 +1,133 @@
+package explore
+
+import (
+	"regexp"
+	"strings"
+)
+
+// wordlists were written for grep, so entries are posix basic regular expressions, which are
+// converted to the re2 syntax used by the regexp package
+func convertBreToRegex(pattern string) string {
+	converted := strings.Builder{}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			escaped := pattern[i]
+			switch escaped {
+			case '(', ')', '{', '}', '|', '+', '?':
+				converted.WriteByte(escaped)
+			case 'w', 'W', 's', 'S', 'b', 'B':
+				converted.WriteByte('\\')
+				converted.WriteByte(escaped)
+			case '<', '>':
+				converted.WriteString(`\b`)
+			default:
+				converted.WriteString(regexp.QuoteMeta(string(escaped)))
+			}
+		case c == '[':
+			end := findBracketExpressionEnd(pattern, i)
+			if end < 0 {
+				converted.WriteString(regexp.QuoteMeta(pattern[i:]))
+				return converted.String()
+			}
+			// backslashes are literal inside posix bracket expressions
+			converted.WriteString(strings.ReplaceAll(pattern[i:end+1], `\`, `\\`))
+			i = end
+		case c == '*' && i == 0:
+			converted.WriteString(`\*`)
+		case strings.IndexByte("+?(){}|", c) >= 0:
+			converted.WriteString(regexp.QuoteMeta(string(c)))
+		default:
+			converted.WriteByte(c)
+		}
+	}
+	return converted.String()
+}
+
+func findBracketExpressionEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '^' {
+		i++
+	}
+	// a closing bracket straight after the opening one is part of the set
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		if pattern[i] == '[' && i+1 < len(pattern) && strings.IndexByte(":.=", pattern[i+1]) >= 0 {
+			classEnd := strings.Index(pattern[i+2:], string(pattern[i+1])+"]")
+			if classEnd >= 0 {
+				i += classEnd + 3
+				continue
+			}
+		}
+		if pattern[i] == ']' {
+			return i
+		}
+	}
+	return -1
+}
+
+// findRequiredLiteral returns the longest run of plain characters that every match of the pattern
+// has to contain, lowercased, or an empty string if there is none
+func findRequiredLiteral(pattern string) string {
+	if strings.Contains(pattern, `\|`) {
+		return ""
+	}
+
+	longest := ""
+	current := strings.Builder{}
+	endRun := func() {
+		if current.Len() > len(longest) {
+			longest = current.String()
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		literal := -1
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			if pattern[i] == '{' {
+				// the bounds of an interval are not part of the text
+				if end := strings.Index(pattern[i:], `\}`); end >= 0 {
+					i += end + 1
+				}
+			} else if strings.IndexByte("(){}|+?wWsSbB<>0123456789", pattern[i]) < 0 {
+				literal = int(pattern[i])
+			} else if pattern[i] == ')' && isOptionalQuantifier(pattern, i+1) {
+				return ""
+			}
+		case c == '[':
+			if end := findBracketExpressionEnd(pattern, i); end >= 0 {
+				i = end
+			}
+		case c == '.' || c == '^' || c == '$' || (c == '*' && i > 0):
+		default:
+			literal = int(c)
+		}
+
+		if literal < 0 {
+			endRun()
+			continue
+		}
+		if isOptionalQuantifier(pattern, i+1) {
+			endRun()
+			continue
+		}
+		current.WriteByte(byte(literal))
+		if strings.HasPrefix(pattern[i+1:], `\+`) {
+			endRun()
+		}
+	}
+	endRun()
+	return strings.ToLower(longest)
+}
+
+func isOptionalQuantifier(pattern string, i int) bool {
+	return strings.HasPrefix(pattern[i:], "*") || strings.HasPrefix(pattern[i:], `\?`) || strings.HasPrefix(pattern[i:], `\{`)
+}
@@ -1,3 +1,48 @@
 package explore
 
-var DUPLICATE_RESULTS_THRESHOLD = 20
+import "time"
+
+var MAX_ENTROPY_SCORE = 4.5
+var MAX_LENGTH_SCORE = 32
+var VARIABLE_PATTERN_KIND = "variable"
+var KEYWORD_PATTERN_KIND = "keyword"
+var SIGNATURE_PATTERN_KIND = "signature"
+var VERIFICATION_LIVE = "live"
+var VERIFICATION_REVOKED = "revoked"
+var VERIFICATION_UNKNOWN = "unknown"
+var VERIFICATION_TIMEOUT = 10 * time.Second
+var AWS_SECRET_KEY_SEARCH_LINES = 2
+var AWS_REGION = "us-east-1"
+var AWS_GET_CALLER_IDENTITY_BODY = "Action=GetCallerIdentity&Version=2011-06-15"
+var MULTILINE_PATTERN_KIND = "multiline"
+var MAX_MULTILINE_BLOCK_LINES = 200
+var PEM_BODY_HASH_LENGTH = 16
+var PRIVATE_KEY_RULE = "private-key"
+var PRIVATE_KEY_BLOCK_RULE = "private-key-block"
+var MAX_DECODE_DEPTH = 3
+var MIN_DECODED_LENGTH = 8
+var MIN_DECODED_PRINTABLE_RATIO = 0.95
+var MASK_LEAK_PATTERN_KIND = "mask-leak"
+var MASKED_VALUE_RULE = "masked-value-printed"
+var MASKED_VARIABLE_RULE = "masked-variable-printed"
+var ADD_MASK_COMMAND = "add-mask"
+var MIN_MASKED_VALUE_LENGTH = 4
+var REDACTED_TEXT = "[REDACTED]"
+var RULE_PATTERN_KIND = "rule"
+var HIGH_SEVERITY = "high"
+var ENV_DUMP_PATTERN_KIND = "env-dump"
+var ENV_DUMP_RULE = "environment-dump"
+var SHELL_TRACE_RULE = "shell-trace"
+var DUMPED_VARIABLE_RULE = "dumped-variable"
+var MIN_ENV_DUMP_LINES = 5
+var MIN_ENV_DUMP_RUNNER_VARIABLES = 3
+var MAX_ENV_DUMP_GAP_LINES = 50
+var MIN_SHELL_TRACE_LINES = 3
+var DEPENDENCY_PATTERN_KIND = "dependency"
+var NPM_DEPENDENCY_RULE = "npm-dependency-confusion"
+var PYPI_DEPENDENCY_RULE = "pypi-dependency-confusion"
+var RUBYGEMS_DEPENDENCY_RULE = "rubygems-dependency-confusion"
+var COMPOSER_DEPENDENCY_RULE = "composer-dependency-confusion"
+var GO_DEPENDENCY_RULE = "go-dependency-confusion"
+var PACKAGE_CLAIMED = "claimed"
+var PACKAGE_UNCLAIMED = "unclaimed"
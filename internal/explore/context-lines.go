@@ -0,0 +1,99 @@
+package explore
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bm402/gander/internal/actionslog"
+	"github.com/bm402/gander/internal/logger"
+)
+
+type ContextLine struct {
+	Number  int
+	Text    string
+	IsMatch bool
+}
+
+// addContextToResult reads the lines around the first occurrence of a result, like grep -C, with
+// the timestamps taken off and the secret replaced if it is to be redacted
+func addContextToResult(result CollectedResult, pattern *searchPattern, matchedString string, opts SearchOptions) CollectedResult {
+	if opts.ContextBefore <= 0 && opts.ContextAfter <= 0 {
+		return result
+	}
+	startLine, err := strconv.Atoi(result.Line)
+	if err != nil {
+		return result
+	}
+	endLine := startLine
+	if result.EndLine != "" {
+		if endLine, err = strconv.Atoi(result.EndLine); err != nil {
+			return result
+		}
+	}
+
+	file, err := os.Open(result.Filename)
+	if err != nil {
+		return result
+	}
+	defer file.Close()
+
+	secret := getSecretValue(pattern, matchedString)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), actionslog.MAX_LINE_LENGTH)
+	for lineNumber := 1; scanner.Scan() && lineNumber <= endLine+opts.ContextAfter; lineNumber++ {
+		if lineNumber < startLine-opts.ContextBefore {
+			continue
+		}
+		contextLine := ContextLine{
+			Number:  lineNumber,
+			Text:    actionslog.ParseLine(scanner.Text()).Content,
+			IsMatch: lineNumber >= startLine && lineNumber <= endLine,
+		}
+		if opts.IsRedact {
+			contextLine.Text = redactSecret(contextLine, pattern, secret, result.Encoding != "")
+		}
+		result.Context = append(result.Context, contextLine)
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Print("gander", "", "context-lines", "Could not read all the lines around", result.Filename+":"+result.Line+":", err.Error())
+	}
+	return result
+}
+
+// getSecretValue returns the part of a matched string that is the secret, or an empty string if
+// the match is a phrase that is not secret in itself
+func getSecretValue(pattern *searchPattern, matchedString string) string {
+	switch {
+	case pattern.kind == VARIABLE_PATTERN_KIND || pattern.source == MASKED_VARIABLE_RULE || pattern.source == DUMPED_VARIABLE_RULE:
+		return getAssignedValue(matchedString)
+	case pattern.kind == KEYWORD_PATTERN_KIND || pattern.kind == DEPENDENCY_PATTERN_KIND || pattern.source == ENV_DUMP_RULE ||
+		pattern.source == SHELL_TRACE_RULE:
+		return ""
+	default:
+		return matchedString
+	}
+}
+
+func redactSecret(contextLine ContextLine, pattern *searchPattern, secret string, isEncoded bool) string {
+	// the whole of a multiline secret is on the matched lines, and an encoded secret cannot be picked out of its line
+	if pattern.kind == MULTILINE_PATTERN_KIND || isEncoded {
+		if contextLine.IsMatch {
+			return REDACTED_TEXT
+		}
+		return contextLine.Text
+	}
+	if secret == "" {
+		return contextLine.Text
+	}
+	return strings.ReplaceAll(contextLine.Text, secret, REDACTED_TEXT)
+}
+
+// redactMatchedString replaces the secret in the label of a result, so it is not shown in the output
+func redactMatchedString(matchedString, secret string) string {
+	if secret == "" {
+		return matchedString
+	}
+	return strings.ReplaceAll(matchedString, secret, REDACTED_TEXT)
+}
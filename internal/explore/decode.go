@@ -0,0 +1,111 @@
+package explore
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var base64Regex = regexp.MustCompile(`[A-Za-z0-9+/_\-]{20,}={0,2}`)
+var hexRegex = regexp.MustCompile(`\b(?:[0-9A-Fa-f]{2}){16,}\b`)
+var percentEncodedRegex = regexp.MustCompile(`[^\s%]*(?:%[0-9A-Fa-f]{2}[^\s%]*){3,}`)
+
+type decodedBlob struct {
+	encoding string
+	text     string
+}
+
+// searchEncoded decodes the base64, hex and percent encoded runs in a line and searches the decoded
+// text with every pattern, recursing into anything encoded more than once
+func (e *searchEngine) searchEncoded(text string, seen []bool, chain string, depth int) []searchMatch {
+	matches := []searchMatch{}
+	if depth > MAX_DECODE_DEPTH {
+		return matches
+	}
+
+	for _, blob := range findEncodedBlobs(text) {
+		encoding := blob.encoding
+		if chain != "" {
+			encoding = chain + " > " + blob.encoding
+		}
+
+		detectors := []multilineDetector{}
+		for _, pattern := range e.multiline {
+			detectors = append(detectors, pattern.newDetector())
+		}
+		decodedMatches := []searchMatch{}
+		for idx, line := range strings.Split(blob.text, "\n") {
+			line = strings.TrimRight(line, "\r")
+			decodedMatches = append(decodedMatches, e.searchLine(line, seen)...)
+			for detectorIdx, detector := range detectors {
+				decodedMatches = append(decodedMatches, createMultilineMatches(e.multiline[detectorIdx], detector.addLine(line, idx+1))...)
+			}
+			decodedMatches = append(decodedMatches, e.searchEncoded(line, seen, encoding, depth+1)...)
+		}
+		for detectorIdx, detector := range detectors {
+			decodedMatches = append(decodedMatches, createMultilineMatches(e.multiline[detectorIdx], detector.end())...)
+		}
+
+		for _, match := range decodedMatches {
+			// lines inside the decoded text do not exist in the file
+			match.endLine = ""
+			if match.encoding == "" {
+				match.encoding = encoding
+			}
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+func findEncodedBlobs(text string) []decodedBlob {
+	blobs := []decodedBlob{}
+	for _, candidate := range hexRegex.FindAllString(text, -1) {
+		if decoded, err := hex.DecodeString(candidate); err == nil && isDecodedText(decoded) {
+			blobs = append(blobs, decodedBlob{"hex", string(decoded)})
+		}
+	}
+	for _, candidate := range base64Regex.FindAllString(text, -1) {
+		if decoded, exists := decodeBase64(candidate); exists {
+			blobs = append(blobs, decodedBlob{"base64", string(decoded)})
+		}
+	}
+	for _, candidate := range percentEncodedRegex.FindAllString(text, -1) {
+		if decoded, err := url.PathUnescape(candidate); err == nil && isDecodedText([]byte(decoded)) {
+			blobs = append(blobs, decodedBlob{"url", decoded})
+		}
+	}
+	return blobs
+}
+
+func decodeBase64(candidate string) ([]byte, bool) {
+	trimmed := strings.TrimRight(candidate, "=")
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(trimmed, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	decoded, err := encoding.DecodeString(trimmed)
+	if err != nil || !isDecodedText(decoded) {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// most runs of letters and digits decode to binary noise, so only decoded text is searched
+func isDecodedText(decoded []byte) bool {
+	if len(decoded) < MIN_DECODED_LENGTH || !utf8.Valid(decoded) {
+		return false
+	}
+	total, printable := 0, 0
+	for _, r := range string(decoded) {
+		total++
+		if unicode.IsPrint(r) || r == '\n' || r == '\r' || r == '\t' {
+			printable++
+		}
+	}
+	return float64(printable)/float64(total) >= MIN_DECODED_PRINTABLE_RATIO
+}
@@ -0,0 +1,217 @@
+package explore
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/bm402/gander/internal/logger"
+)
+
+var pypiNormaliseRegex = regexp.MustCompile(`[-_.]+`)
+
+// module paths on these hosts are owned by accounts that cannot be looked up without a token
+var goHostingDomains = map[string]bool{
+	"gitlab.com":    true,
+	"bitbucket.org": true,
+	"gopkg.in":      true,
+}
+
+// the errors package managers print when a dependency is missing from the registry they looked in,
+// which for internal packages means the name could be registered by anyone on the public registry
+var dependencySignatures = []signature{
+	{NPM_DEPENDENCY_RULE, "is not in ", `'((?:@[^/'\s]+/)?[^@'\s]+)(?:@[^'\s]*)?' is not in (?:the npm|this) registry`},
+	{PYPI_DEPENDENCY_RULE, "no matching distribution found for ", `No matching distribution found for ([A-Za-z0-9][A-Za-z0-9._-]*)`},
+	{RUBYGEMS_DEPENDENCY_RULE, "could not find ", `Could not find (?:a valid )?gem '([^'\s]+)`},
+	{COMPOSER_DEPENDENCY_RULE, "could not find ", `Could not find (?:a matching version of )?package ((?i)[a-z0-9](?:[_.-]?[a-z0-9]+)*/[a-z0-9](?:[_.-]?[a-z0-9]+)*)`},
+	{GO_DEPENDENCY_RULE, "go: ", `go: ((?:[a-z0-9-]+\.)+[a-z]{2,}(?:/[A-Za-z0-9._~-]+)+)(?:@[^:\s]+)?: .*(?:404 Not Found|410 Gone|unrecognized import path|repository not found)`},
+}
+
+func createDependencyPatterns() []*searchPattern {
+	patterns := []*searchPattern{}
+	for _, signature := range dependencySignatures {
+		patterns = append(patterns, &searchPattern{
+			kind:    DEPENDENCY_PATTERN_KIND,
+			source:  signature.name,
+			literal: signature.literal,
+			regex:   regexp.MustCompile(signature.regex),
+		})
+	}
+	return patterns
+}
+
+type matchLocation struct {
+	filename string
+	line     string
+}
+
+// the keyword wordlist has the same errors as the dependency signatures, so keyword matches on the
+// lines a dependency was found on are left out rather than reported twice
+func removeDependencyKeywordMatches(matches []searchMatch) []searchMatch {
+	dependencyLines := make(map[matchLocation]bool)
+	for _, match := range matches {
+		if match.pattern.kind == DEPENDENCY_PATTERN_KIND {
+			dependencyLines[matchLocation{match.filename, match.line}] = true
+		}
+	}
+	remainingMatches := []searchMatch{}
+	for _, match := range matches {
+		if match.pattern.kind == KEYWORD_PATTERN_KIND && dependencyLines[matchLocation{match.filename, match.line}] {
+			continue
+		}
+		remainingMatches = append(remainingMatches, match)
+	}
+	return remainingMatches
+}
+
+type RegistryOptions struct {
+	NpmUrl       string
+	PypiUrl      string
+	RubygemsUrl  string
+	PackagistUrl string
+	GoProxyUrl   string
+	GitHubUrl    string
+	RdapUrl      string
+}
+
+// registryVerifier looks a package up on a public registry, where a missing package is unclaimed
+// unless the namespace it is in belongs to someone, since then nobody else can register it
+type registryVerifier struct {
+	rule           string
+	baseUrl        string
+	createPath     func(name string) string
+	checkNamespace func(name string) (string, error)
+	httpClient     *http.Client
+}
+
+// namespaceChecker works out whether the owner of a missing package name could be registered
+type namespaceChecker struct {
+	opts       RegistryOptions
+	httpClient *http.Client
+}
+
+func CreateRegistryVerifiers(opts RegistryOptions) []Verifier {
+	httpClient := &http.Client{
+		Timeout: VERIFICATION_TIMEOUT,
+	}
+	checker := &namespaceChecker{opts: opts, httpClient: httpClient}
+	return []Verifier{
+		&registryVerifier{NPM_DEPENDENCY_RULE, opts.NpmUrl, createNpmPath, checker.checkNpmScope, httpClient},
+		&registryVerifier{PYPI_DEPENDENCY_RULE, opts.PypiUrl, createPypiPath, nil, httpClient},
+		&registryVerifier{RUBYGEMS_DEPENDENCY_RULE, opts.RubygemsUrl, createRubygemsPath, nil, httpClient},
+		&registryVerifier{COMPOSER_DEPENDENCY_RULE, opts.PackagistUrl, createPackagistPath, nil, httpClient},
+		&registryVerifier{GO_DEPENDENCY_RULE, opts.GoProxyUrl, createGoProxyPath, checker.checkGoModulePath, httpClient},
+	}
+}
+
+func (v *registryVerifier) Supports(rule string) bool {
+	return rule == v.rule
+}
+
+func (v *registryVerifier) Verify(name string, result CollectedResult) (string, error) {
+	status, err := getRegistryStatus(v.httpClient, v.baseUrl, v.createPath(name))
+	if err != nil || status != PACKAGE_UNCLAIMED || v.checkNamespace == nil {
+		return status, err
+	}
+	return v.checkNamespace(name)
+}
+
+// getRegistryStatus treats anything that is found as claimed and anything missing as unclaimed
+func getRegistryStatus(httpClient *http.Client, baseUrl, path string) (string, error) {
+	resp, err := httpClient.Get(strings.TrimSuffix(baseUrl, "/") + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return PACKAGE_CLAIMED, nil
+	case http.StatusNotFound, http.StatusGone:
+		return PACKAGE_UNCLAIMED, nil
+	default:
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+// only the owner of a scope can publish to it, and scopes are either organisations or users
+func (c *namespaceChecker) checkNpmScope(name string) (string, error) {
+	if !strings.HasPrefix(name, "@") || !strings.Contains(name, "/") {
+		return PACKAGE_UNCLAIMED, nil
+	}
+	scope := url.PathEscape(name[1:strings.Index(name, "/")])
+	for _, path := range []string{"/-/org/" + scope + "/package", "/-/user/" + scope + "/package"} {
+		status, err := getRegistryStatus(c.httpClient, c.opts.NpmUrl, path)
+		if err != nil || status == PACKAGE_CLAIMED {
+			return status, err
+		}
+	}
+	return PACKAGE_UNCLAIMED, nil
+}
+
+// the go proxy is missing every private module, so a module path can only be claimed if the github
+// account or the domain it is under can be
+func (c *namespaceChecker) checkGoModulePath(name string) (string, error) {
+	parts := strings.Split(name, "/")
+	switch {
+	case parts[0] == "github.com" && len(parts) > 1:
+		return getRegistryStatus(c.httpClient, c.opts.GitHubUrl, "/users/"+url.PathEscape(parts[1]))
+	case goHostingDomains[parts[0]]:
+		return VERIFICATION_UNKNOWN, nil
+	default:
+		return getRegistryStatus(c.httpClient, c.opts.RdapUrl, "/domain/"+getRegisteredDomain(parts[0]))
+	}
+}
+
+// the registered domain is taken as the last two labels, which is wrong for domains like co.uk
+// but those are rare in module paths
+func getRegisteredDomain(host string) string {
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
+
+// scoped packages have the slash between the scope and the name escaped
+func createNpmPath(name string) string {
+	return "/" + url.PathEscape(name)
+}
+func createPypiPath(name string) string {
+	return "/pypi/" + pypiNormaliseRegex.ReplaceAllString(strings.ToLower(name), "-") + "/json"
+}
+
+func createRubygemsPath(name string) string {
+	return "/api/v1/gems/" + url.PathEscape(name) + ".json"
+}
+
+func createPackagistPath(name string) string {
+	return "/p2/" + strings.ToLower(name) + ".json"
+}
+
+// the go proxy escapes upper case letters in module paths as an exclamation mark and the lower case letter
+func createGoProxyPath(name string) string {
+	escaped := strings.Builder{}
+	for _, r := range name {
+		if unicode.IsUpper(r) {
+			escaped.WriteRune('!')
+			r = unicode.ToLower(r)
+		}
+		escaped.WriteRune(r)
+	}
+	return "/" + escaped.String() + "/@v/list"
+}
+
+// RemoveClaimedPackages leaves out the missing dependencies that exist on the public registry, since
+// only unclaimed names can be registered by someone else
+func RemoveClaimedPackages(owner, repo string, collectedResults map[string]CollectedResult) {
+	for matchedString, result := range collectedResults {
+		if result.Verification == PACKAGE_CLAIMED {
+			logger.Print(owner, repo, "dependency-confusion", matchedString, "is already claimed on the public registry")
+			delete(collectedResults, matchedString)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package explore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistryVerifiers(t *testing.T) {
+	statuses := map[string]int{
+		"/left-pad":                                    http.StatusOK,
+		"/internal-utils":                              http.StatusNotFound,
+		"/broken":                                      http.StatusInternalServerError,
+		"/@acme%2Finternal-utils":                      http.StatusNotFound,
+		"/-/org/acme/package":                          http.StatusOK,
+		"/@unowned%2Futils":                            http.StatusNotFound,
+		"/-/org/unowned/package":                       http.StatusNotFound,
+		"/-/user/unowned/package":                      http.StatusNotFound,
+		"/pypi/requests/json":                          http.StatusOK,
+		"/pypi/acme-internal-lib/json":                 http.StatusNotFound,
+		"/api/v1/gems/rails.json":                      http.StatusOK,
+		"/api/v1/gems/acme-private.json":               http.StatusNotFound,
+		"/p2/acme/billing.json":                        http.StatusNotFound,
+		"/p2/symfony/console.json":                     http.StatusOK,
+		"/github.com/!acme/private/@v/list":            http.StatusGone,
+		"/users/Acme":                                  http.StatusOK,
+		"/github.com/gone-org/lib/@v/list":             http.StatusNotFound,
+		"/users/gone-org":                              http.StatusNotFound,
+		"/corp.example.com/tools/@v/list":              http.StatusNotFound,
+		"/domain/example.com":                          http.StatusOK,
+		"/go.unregistered.dev/lib/@v/list":             http.StatusNotFound,
+		"/domain/unregistered.dev":                     http.StatusNotFound,
+		"/gitlab.com/acme/lib/@v/list":                 http.StatusNotFound,
+		"/golang.org/x/text/@v/list":                   http.StatusOK,
+		"/github.com/!acme/private/sub/module/@v/list": http.StatusNotFound,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, exists := statuses[r.URL.EscapedPath()]
+		if !exists {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+			status = http.StatusTeapot
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	verifiers := CreateRegistryVerifiers(RegistryOptions{
+		NpmUrl:       server.URL,
+		PypiUrl:      server.URL + "/",
+		RubygemsUrl:  server.URL,
+		PackagistUrl: server.URL,
+		GoProxyUrl:   server.URL,
+		GitHubUrl:    server.URL,
+		RdapUrl:      server.URL,
+	})
+
+	tests := []struct {
+		rule    string
+		name    string
+		status  string
+		isError bool
+	}{
+		{NPM_DEPENDENCY_RULE, "left-pad", PACKAGE_CLAIMED, false},
+		{NPM_DEPENDENCY_RULE, "internal-utils", PACKAGE_UNCLAIMED, false},
+		{NPM_DEPENDENCY_RULE, "broken", "", true},
+		{NPM_DEPENDENCY_RULE, "@acme/internal-utils", PACKAGE_CLAIMED, false},
+		{NPM_DEPENDENCY_RULE, "@unowned/utils", PACKAGE_UNCLAIMED, false},
+		{PYPI_DEPENDENCY_RULE, "requests", PACKAGE_CLAIMED, false},
+		{PYPI_DEPENDENCY_RULE, "Acme_Internal.Lib", PACKAGE_UNCLAIMED, false},
+		{RUBYGEMS_DEPENDENCY_RULE, "rails", PACKAGE_CLAIMED, false},
+		{RUBYGEMS_DEPENDENCY_RULE, "acme-private", PACKAGE_UNCLAIMED, false},
+		{COMPOSER_DEPENDENCY_RULE, "Acme/Billing", PACKAGE_UNCLAIMED, false},
+		{COMPOSER_DEPENDENCY_RULE, "symfony/console", PACKAGE_CLAIMED, false},
+		{GO_DEPENDENCY_RULE, "github.com/Acme/private", PACKAGE_CLAIMED, false},
+		{GO_DEPENDENCY_RULE, "github.com/gone-org/lib", PACKAGE_UNCLAIMED, false},
+		{GO_DEPENDENCY_RULE, "corp.example.com/tools", PACKAGE_CLAIMED, false},
+		{GO_DEPENDENCY_RULE, "go.unregistered.dev/lib", PACKAGE_UNCLAIMED, false},
+		{GO_DEPENDENCY_RULE, "gitlab.com/acme/lib", VERIFICATION_UNKNOWN, false},
+		{GO_DEPENDENCY_RULE, "golang.org/x/text", PACKAGE_CLAIMED, false},
+		{GO_DEPENDENCY_RULE, "github.com/Acme/private/sub/module", PACKAGE_CLAIMED, false},
+	}
+	for _, test := range tests {
+		verifier := findVerifier(verifiers, test.rule)
+		if verifier == nil {
+			t.Fatalf("no verifier for %s", test.rule)
+		}
+		assertVerification(t, verifier, test.name, CollectedResult{}, test.status, test.isError)
+	}
+}
+
+func TestDependencyPatterns(t *testing.T) {
+	tests := []struct {
+		line string
+		rule string
+		name string
+	}{
+		{"npm ERR! 404  '@acme/internal-utils@^1.2.0' is not in this registry.", NPM_DEPENDENCY_RULE, "@acme/internal-utils"},
+		{"npm ERR! 404  'internal-utils@latest' is not in the npm registry.", NPM_DEPENDENCY_RULE, "internal-utils"},
+		{"ERROR: No matching distribution found for acme-lib==2.0", PYPI_DEPENDENCY_RULE, "acme-lib"},
+		{"Could not find a valid gem 'acme-private' (>= 0) in any repository", RUBYGEMS_DEPENDENCY_RULE, "acme-private"},
+		{"Could not find gem 'rails (= 99.0)' in rubygems repository https://rubygems.org/", RUBYGEMS_DEPENDENCY_RULE, "rails"},
+		{"[InvalidArgumentException] Could not find package acme/billing.", COMPOSER_DEPENDENCY_RULE, "acme/billing"},
+		{"go: github.com/acme/lib@v1.0.0: reading https://proxy.golang.org/github.com/acme/lib/@v/v1.0.0.mod: 404 Not Found", GO_DEPENDENCY_RULE, "github.com/acme/lib"},
+	}
+	patterns := createDependencyPatterns()
+	for _, test := range tests {
+		pattern := findPatternBySource(patterns, test.rule)
+		matchedStrings := findAllMatchedStrings(pattern, test.line)
+		if len(matchedStrings) != 1 || matchedStrings[0] != test.name {
+			t.Errorf("expected %s to match %s, got %v", test.line, test.name, matchedStrings)
+		}
+	}
+}
+
+func TestRemoveClaimedPackages(t *testing.T) {
+	collectedResults := map[string]CollectedResult{
+		"[npm-dependency-confusion] left-pad":       {Rule: NPM_DEPENDENCY_RULE, Verification: PACKAGE_CLAIMED},
+		"[npm-dependency-confusion] internal-utils": {Rule: NPM_DEPENDENCY_RULE, Verification: PACKAGE_UNCLAIMED},
+		"[go-dependency-confusion] gitlab.com/a/b":  {Rule: GO_DEPENDENCY_RULE, Verification: VERIFICATION_UNKNOWN},
+	}
+	RemoveClaimedPackages("owner", "repo", collectedResults)
+	if _, exists := collectedResults["[npm-dependency-confusion] left-pad"]; exists {
+		t.Errorf("expected claimed package to be removed")
+	}
+	if len(collectedResults) != 2 {
+		t.Errorf("expected unclaimed and unknown packages to be kept, got %v", collectedResults)
+	}
+}
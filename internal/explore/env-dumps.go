@@ -0,0 +1,165 @@
+package explore
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bm402/gander/internal/actionslog"
+)
+
+var envDumpCommandRegex = regexp.MustCompile(`(^|[;&|]\s*|^\++\s+)(env|printenv|export -p|set|declare -p|declare -x|typeset -x|Get-ChildItem\s+env:|gci\s+env:|dir\s+env:)\s*($|[;&|])`)
+var envDumpLineRegex = regexp.MustCompile(`^(?:declare -x |export |typeset -x )?([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+var xtraceEnabledRegex = regexp.MustCompile(`\bset\s+(-[a-wyz]*x|-o\s+xtrace)|\b(ba)?sh\s+(-[a-z]*x)`)
+var ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+var xtraceLineRegex = regexp.MustCompile(`^\++ \S`)
+
+// a traced line shows a command being run, which diffs and changelogs that also start with + do not
+var xtraceCommandRegex = regexp.MustCompile(`^\++ (?:[A-Za-z_][A-Za-z0-9_]*=\S*(?:\s|$)|\.{0,2}/\S|(?:echo|printf|cd|export|source|eval|set|test|\[\[?|bash|sh|curl|wget|git|docker|kubectl|helm|terraform|aws|gcloud|az|npm|npx|yarn|pnpm|node|python3?|pip3?|go|make|mkdir|rm|cp|mv|cat|ls|chmod|tar|unzip|ssh|scp|sed|awk|grep|jq)(?:\s|$))`)
+var xtraceAssignmentRegex = regexp.MustCompile(`(?:^|\s)([A-Za-z_][A-Za-z0-9_]*)=('[^']*'|"[^"]*"|[^\s'"]+)`)
+
+// runner variables are in every environment dump, so they show that a block of assignments is one,
+// and most of them are not secret
+// inputs, state and the runtime tokens are runner variables that hold secrets
+var secretRunnerVariableRegex = regexp.MustCompile(`^(INPUT_|STATE_|ACTIONS_)`)
+
+var runnerVariableRegex = regexp.MustCompile(`^(GITHUB_|RUNNER_|ACTIONS_|INPUT_|STATE_|JAVA_HOME|GOROOT|ANDROID_|AGENT_|CHROME|EDGE|GECKO|SELENIUM|PIPX_|CONDA|VCPKG|DOTNET_|NVM_|BOOTSTRAP_|ImageOS$|ImageVersion$|PATH$|HOME$|USER$|SHELL$|PWD$|OLDPWD$|SHLVL$|LANG$|LC_|TERM$|HOSTNAME$|_$|CI$|DEBIAN_FRONTEND$|XDG_|INVOCATION_ID$|JOURNAL_STREAM$|SYSTEMD_|ACCEPT_EULA$|AZURE_EXTENSION_DIR$|LEIN_|SWIFT_PATH$|POWERSHELL_|GRADLE_HOME$|ANT_HOME$|M2_HOME$|HOMEBREW_|PERFLOG_|RUSTUP_|CARGO_HOME$|GHCUP_|BASH|PS[1-4]$|IFS$|OPTERR$|OPTIND$|PPID$|UID$|EUID$|GROUPS$|HOSTTYPE$|MACHTYPE$|OSTYPE$|DIRSTACK$|HISTFILESIZE$|HISTSIZE$|SHELLOPTS$)`)
+
+func createEnvDumpPatterns() []*searchPattern {
+	return []*searchPattern{
+		{kind: ENV_DUMP_PATTERN_KIND, source: ENV_DUMP_RULE},
+		{kind: ENV_DUMP_PATTERN_KIND, source: SHELL_TRACE_RULE},
+		{kind: ENV_DUMP_PATTERN_KIND, source: DUMPED_VARIABLE_RULE},
+	}
+}
+
+type envDumpBlock struct {
+	lines []actionslog.Line
+}
+
+// findEnvironmentDumps reports each step that printed its environment with env, printenv, export -p
+// or set, or traced its commands with set -x, along with every variable that was printed
+func (e *searchEngine) findEnvironmentDumps(run actionslog.Run) []searchMatch {
+	matches := []searchMatch{}
+	for _, job := range run.Jobs {
+		for _, step := range job.Steps {
+			matches = append(matches, e.findStepEnvironmentDumps(job, step)...)
+		}
+	}
+	return matches
+}
+
+func (e *searchEngine) findStepEnvironmentDumps(job *actionslog.Job, step *actionslog.Step) []searchMatch {
+	hasDumpCommand, hasXtrace := false, false
+	tracedLines := []actionslog.Line{}
+	tracedCommands := 0
+	for _, line := range step.Lines {
+		command := getCommandText(line)
+		if envDumpCommandRegex.MatchString(command) {
+			hasDumpCommand = true
+		}
+		if xtraceEnabledRegex.MatchString(command) {
+			hasXtrace = true
+		}
+		if xtraceLineRegex.MatchString(line.Content) {
+			tracedLines = append(tracedLines, line)
+		}
+		if xtraceCommandRegex.MatchString(line.Content) {
+			tracedCommands++
+		}
+	}
+
+	matches := []searchMatch{}
+	stepDescription := "job \"" + job.Name + "\""
+	if stepName := actionslog.FormatStep(step); stepName != "" {
+		stepDescription += " step \"" + stepName + "\""
+	}
+
+	// blocks of assignments are only environment dumps if a dump command ran or they look like one
+	dumpedLines := []actionslog.Line{}
+	for _, block := range findEnvDumpBlocks(step.Lines) {
+		if hasDumpCommand || countRunnerVariables(block) >= MIN_ENV_DUMP_RUNNER_VARIABLES {
+			dumpedLines = append(dumpedLines, block.lines...)
+		}
+	}
+	if len(dumpedLines) > 0 {
+		match := createStepMatch(findPatternBySource(e.envDumps, ENV_DUMP_RULE), job, step, dumpedLines[0],
+			"environment dump in "+stepDescription)
+		match.endLine = strconv.Itoa(dumpedLines[len(dumpedLines)-1].Number)
+		matches = append(matches, match)
+	}
+	for _, line := range dumpedLines {
+		parts := envDumpLineRegex.FindStringSubmatch(line.Content)
+		if runnerVariableRegex.MatchString(parts[1]) && !secretRunnerVariableRegex.MatchString(parts[1]) {
+			continue
+		}
+		matches = append(matches, e.createDumpedVariableMatch(job, step, line, parts[1], parts[2]))
+	}
+
+	// scripts can turn on tracing themselves, so enough traced commands are a trace without set -x in the step
+	if len(tracedLines) > 0 && (hasXtrace || tracedCommands >= MIN_SHELL_TRACE_LINES) {
+		match := createStepMatch(findPatternBySource(e.envDumps, SHELL_TRACE_RULE), job, step, tracedLines[0],
+			"shell trace in "+stepDescription)
+		match.endLine = strconv.Itoa(tracedLines[len(tracedLines)-1].Number)
+		matches = append(matches, match)
+		for _, line := range tracedLines {
+			for _, assignment := range xtraceAssignmentRegex.FindAllStringSubmatch(line.Content, -1) {
+				matches = append(matches, e.createDumpedVariableMatch(job, step, line, assignment[1], assignment[2]))
+			}
+		}
+	}
+	return matches
+}
+
+// getCommandText returns the command a line shows being run, which the runner writes as the message
+// of a Run group and then as coloured script lines
+func getCommandText(line actionslog.Line) string {
+	if line.Command == actionslog.GROUP_COMMAND {
+		return strings.TrimSpace(strings.TrimPrefix(line.Message, "Run "))
+	}
+	return strings.TrimSpace(ansiEscapeRegex.ReplaceAllString(line.Content, ""))
+}
+
+// a block carries on past a few other lines, since a multi-line value in the middle of a dump
+// breaks up its assignments
+func findEnvDumpBlocks(lines []actionslog.Line) []envDumpBlock {
+	blocks := []envDumpBlock{}
+	current := envDumpBlock{}
+	gap := 0
+	for _, line := range lines {
+		if line.Command == "" && envDumpLineRegex.MatchString(line.Content) {
+			current.lines = append(current.lines, line)
+			gap = 0
+			continue
+		}
+		gap++
+		if line.Command == "" && len(current.lines) > 0 && gap <= MAX_ENV_DUMP_GAP_LINES {
+			continue
+		}
+		blocks = appendEnvDumpBlock(blocks, current)
+		current = envDumpBlock{}
+	}
+	return appendEnvDumpBlock(blocks, current)
+}
+
+func appendEnvDumpBlock(blocks []envDumpBlock, block envDumpBlock) []envDumpBlock {
+	if len(block.lines) >= MIN_ENV_DUMP_LINES {
+		return append(blocks, block)
+	}
+	return blocks
+}
+
+func countRunnerVariables(block envDumpBlock) int {
+	count := 0
+	for _, line := range block.lines {
+		if parts := envDumpLineRegex.FindStringSubmatch(line.Content); runnerVariableRegex.MatchString(parts[1]) {
+			count++
+		}
+	}
+	return count
+}
+
+func (e *searchEngine) createDumpedVariableMatch(job *actionslog.Job, step *actionslog.Step, line actionslog.Line, name, value string) searchMatch {
+	value = strings.Trim(value, "\"'")
+	return createStepMatch(findPatternBySource(e.envDumps, DUMPED_VARIABLE_RULE), job, step, line, name+"="+value)
+}
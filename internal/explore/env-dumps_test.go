@@ -0,0 +1,87 @@
+package explore
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/bm402/gander/internal/actionslog"
+)
+
+func findTestStepDumps(contents []string) []string {
+	step := &actionslog.Step{Number: 1, Name: "step"}
+	for idx, text := range contents {
+		line := actionslog.ParseLine(text)
+		line.Number = idx + 1
+		step.Lines = append(step.Lines, line)
+	}
+	engine := newSearchEngine(createEnvDumpPatterns(), false)
+	matchedStrings := []string{}
+	for _, match := range engine.findStepEnvironmentDumps(&actionslog.Job{Name: "job"}, step) {
+		matchedStrings = append(matchedStrings, match.pattern.source+" "+match.matchedString)
+	}
+	sort.Strings(matchedStrings)
+	return matchedStrings
+}
+
+func TestEnvironmentDumpSkipsRunnerVariables(t *testing.T) {
+	matchedStrings := findTestStepDumps([]string{
+		"##[group]Run env",
+		"##[endgroup]",
+		"GITHUB_SHA=4f2b9c1e8d7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c",
+		"RUNNER_NAME=GitHub Actions 2",
+		"INPUT_TOKEN=Hq7Lm2Xp9Rt4Vb6Nc3Wz",
+		"ACTIONS_RUNTIME_TOKEN=eyJhbGciOiJSUzI1NiJ9",
+		"DEPLOY_KEY=Zk8s9dLq2mXv7Pq1Rt5Y",
+	})
+	expected := []string{
+		"dumped-variable ACTIONS_RUNTIME_TOKEN=eyJhbGciOiJSUzI1NiJ9",
+		"dumped-variable DEPLOY_KEY=Zk8s9dLq2mXv7Pq1Rt5Y",
+		"dumped-variable INPUT_TOKEN=Hq7Lm2Xp9Rt4Vb6Nc3Wz",
+		"environment-dump environment dump in job \"job\" step \"1 step\"",
+	}
+	assertMatchedStrings(t, matchedStrings, expected)
+}
+
+func TestShellTraceNeedsTracedCommands(t *testing.T) {
+	diff := findTestStepDumps([]string{
+		"##[group]Run git diff",
+		"##[endgroup]",
+		"+ Added support for rules",
+		"+ Fixed the parser",
+		"+ Removed the old flag",
+	})
+	assertMatchedStrings(t, diff, []string{})
+
+	script := findTestStepDumps([]string{
+		"##[group]Run ./deploy.sh",
+		"##[endgroup]",
+		"+ echo start",
+		"+ API_KEY=Jd82kLm3Qp9Xz7Vb4Nt1",
+		"+ curl -s https://example.com",
+	})
+	assertMatchedStrings(t, script, []string{
+		"dumped-variable API_KEY=Jd82kLm3Qp9Xz7Vb4Nt1",
+		"shell-trace shell trace in job \"job\" step \"1 step\"",
+	})
+
+	xtrace := findTestStepDumps([]string{
+		"##[group]Run set -x",
+		"##[endgroup]",
+		"+ ./build --release",
+	})
+	assertMatchedStrings(t, xtrace, []string{"shell-trace shell trace in job \"job\" step \"1 step\""})
+}
+
+func assertMatchedStrings(t *testing.T, matchedStrings, expected []string) {
+	t.Helper()
+	if len(matchedStrings) != len(expected) {
+		t.Errorf("expected %v, got %v", expected, matchedStrings)
+		return
+	}
+	for idx := range expected {
+		if matchedStrings[idx] != expected[idx] {
+			t.Errorf("expected %v, got %v", expected, matchedStrings)
+			return
+		}
+	}
+}
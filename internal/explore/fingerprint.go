@@ -0,0 +1,42 @@
+package explore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+)
+
+// createFingerprint identifies a finding across scans by what was found and where it came from,
+// rather than by the run or line it happened to be seen on first
+func createFingerprint(rule, value, filename, workflow string) string {
+	valueHash := sha256.Sum256([]byte(normaliseValue(value)))
+	fingerprint := sha256.Sum256([]byte(strings.Join([]string{
+		rule,
+		hex.EncodeToString(valueHash[:]),
+		getRepoOfFilename(filename),
+		workflow,
+	}, "\x00")))
+	return hex.EncodeToString(fingerprint[:])
+}
+
+func normaliseValue(value string) string {
+	return strings.Trim(strings.TrimSpace(value), "\"'")
+}
+
+// getRuleId names the rule or wordlist entry that produced a result
+func getRuleId(pattern *searchPattern) string {
+	if pattern.rule != nil {
+		return pattern.rule.Id
+	}
+	return pattern.source
+}
+
+// results are under owner/repo
+func getRepoOfFilename(filename string) string {
+	parts := strings.SplitN(filepath.ToSlash(filename), "/", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
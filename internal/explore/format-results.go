@@ -0,0 +1,117 @@
+package explore
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bm402/gander/internal/metadata"
+)
+
+func FormatResult(matchedString string, result CollectedResult) string {
+	description := FormatMatchedString(matchedString, result) + " at " + FormatLocation(result) + ", with " + strconv.Itoa(result.Occurrences) +
+		" occurrences in " + strconv.Itoa(result.Files) + " files, confidence " + strconv.FormatFloat(result.Confidence, 'f', 2, 64)
+	if result.Severity != "" {
+		description += ", " + result.Severity + " severity"
+	}
+	if len(result.Tags) > 0 {
+		description += ", tagged " + strings.Join(result.Tags, ", ")
+	}
+	if result.Encoding != "" {
+		description += ", decoded from " + result.Encoding
+	}
+	if result.Verification != "" {
+		description += ", verified " + result.Verification
+	}
+	if run := FormatRun(result.Run); run != "" {
+		description += ", from " + run
+	}
+	if len(result.Context) > 0 {
+		description += "\n" + FormatContext(result.Context)
+	}
+	return description
+}
+
+// FormatMatchedString returns the matched string of a result, without the secret if it is redacted
+func FormatMatchedString(matchedString string, result CollectedResult) string {
+	if result.RedactedString != "" {
+		return result.RedactedString
+	}
+	return matchedString
+}
+
+// FormatContext shows the lines around a result like grep does, with the matched lines marked
+func FormatContext(context []ContextLine) string {
+	lines := []string{}
+	for _, contextLine := range context {
+		separator := "-"
+		if contextLine.IsMatch {
+			separator = ":"
+		}
+		lines = append(lines, "    "+strconv.Itoa(contextLine.Number)+separator+" "+contextLine.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SortResults returns the matched strings of the results with the most likely secrets first
+func SortResults(collectedResults map[string]CollectedResult) []string {
+	matchedStrings := []string{}
+	for matchedString := range collectedResults {
+		matchedStrings = append(matchedStrings, matchedString)
+	}
+	sort.Slice(matchedStrings, func(i, j int) bool {
+		confidenceI := collectedResults[matchedStrings[i]].Confidence
+		confidenceJ := collectedResults[matchedStrings[j]].Confidence
+		if confidenceI != confidenceJ {
+			return confidenceI > confidenceJ
+		}
+		return matchedStrings[i] < matchedStrings[j]
+	})
+	return matchedStrings
+}
+
+func FormatLocation(result CollectedResult) string {
+	location := result.Filename + ":" + result.Line
+	if result.EndLine != "" && result.EndLine != result.Line {
+		location += "-" + result.EndLine
+	}
+
+	details := []string{}
+	if result.Job != "" {
+		details = append(details, "job \""+result.Job+"\"")
+	}
+	if result.Step != "" {
+		details = append(details, "step \""+result.Step+"\"")
+	}
+	if !result.Timestamp.IsZero() {
+		details = append(details, result.Timestamp.UTC().Format(time.RFC3339))
+	}
+	if len(details) > 0 {
+		location += " (" + strings.Join(details, ", ") + ")"
+	}
+	return location
+}
+
+func FormatRun(run metadata.Run) string {
+	if run.Id == 0 {
+		return ""
+	}
+	description := "workflow \"" + run.Workflow + "\" run #" + strconv.Itoa(run.RunNumber)
+	if run.Event != "" {
+		description += " (" + run.Event + ")"
+	}
+	if run.Branch != "" {
+		description += " on " + run.Branch
+	}
+	if len(run.CommitSha) >= 7 {
+		description += " at " + run.CommitSha[:7]
+	}
+	if run.CommitAuthor != "" {
+		description += " by " + run.CommitAuthor
+	}
+	if run.Url != "" {
+		description += " " + run.Url
+	}
+	return description
+}
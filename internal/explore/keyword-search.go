@@ -3,20 +3,15 @@ package explore
 import (
 	"bufio"
 	"os"
-	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/bm402/gander/internal/logger"
+	"github.com/bm402/gander/internal/metadata"
 )
 
-type grepResult struct {
-	filename      string
-	line          string
-	matchedString string
-}
-
 type condensedResultByFileKey struct {
 	filename      string
 	matchedString string
@@ -24,107 +19,158 @@ type condensedResultByFileKey struct {
 
 type condensedResultByFileValue struct {
 	line        string
+	endLine     string
+	encoding    string
+	job         string
+	step        string
+	timestamp   time.Time
 	occurrences int
 }
 
 type CollectedResult struct {
-	Filename    string
-	Line        string
-	Files       int
-	Occurrences int
-	IsCondensed bool
+	Filename     string
+	Line         string
+	EndLine      string
+	Encoding     string
+	Files        int
+	Occurrences  int
+	Confidence   float64
+	Verification string
+	Job          string
+	Step         string
+	Timestamp    time.Time
+	Run          metadata.Run
+	Rule         string
+	Context      []ContextLine
+	Fingerprint  string
+	Severity     string
+	Tags         []string
+	FilterReason string
+	Value        string
+
+	// the matched string with the secret taken out, shown in its place when secrets are redacted
+	RedactedString string
+}
+
+type SearchOptions struct {
+	VariablesWordlistPath string
+	KeywordsWordlistPath  string
+	RulesPaths            []string
+	MinConfidence         float64
+	IsSignatures          bool
+	IsDecode              bool
+	IsMaskLeaks           bool
+	IsEnvDumps            bool
+	IsDependencyConfusion bool
+	ContextBefore         int
+	ContextAfter          int
+	IsRedact              bool
+	PlaceholdersPath      string
+	IsShowFiltered        bool
+	Suppression           *Suppression
 }
 
-func SearchLogsForVariableAssignments(owner, repo, wordlistPath string, threads int) map[string]CollectedResult {
-	variableNames := getWordsFromWordlist(wordlistPath)
-	logger.Print(owner, repo, "search-variables", "Read", len(variableNames), "variable names from wordlist")
+func SearchLogs(owner, repo string, opts SearchOptions, threads int) map[string]CollectedResult {
+	rules := []*Rule{}
+	if len(opts.VariablesWordlistPath) > 0 {
+		variableNames := getWordsFromWordlist(opts.VariablesWordlistPath)
+		logger.Print(owner, repo, "search-variables", "Read", len(variableNames), "variable names from wordlist")
+		rules = append(rules, createRulesFromWordlist(VARIABLE_PATTERN_KIND, variableNames)...)
+	} else {
+		logger.Print(owner, repo, "search-logs", "No variable names wordlist provided")
+	}
+	if len(opts.KeywordsWordlistPath) > 0 {
+		keywords := getWordsFromWordlist(opts.KeywordsWordlistPath)
+		logger.Print(owner, repo, "search-keywords", "Read", len(keywords), "keywords from wordlist")
+		rules = append(rules, createRulesFromWordlist(KEYWORD_PATTERN_KIND, keywords)...)
+	} else {
+		logger.Print(owner, repo, "search-logs", "No keywords wordlist provided")
+	}
+	for _, rulesPath := range opts.RulesPaths {
+		rulesFromFile, err := readRulesFile(rulesPath)
+		if err != nil {
+			logger.Print(owner, repo, "search-rules", "Could not read rules from", rulesPath+":", err.Error())
+			continue
+		}
+		logger.Print(owner, repo, "search-rules", "Read", len(rulesFromFile), "rules from", rulesPath)
+		rules = append(rules, rulesFromFile...)
+	}
+	patterns := createPatternsFromRules(owner, repo, rules)
+	if opts.IsSignatures {
+		patterns = append(patterns, createSignaturePatterns()...)
+		patterns = append(patterns, createMultilinePatterns()...)
+	}
+	if opts.IsMaskLeaks {
+		patterns = append(patterns, createMaskLeakPatterns()...)
+	}
+	if opts.IsEnvDumps {
+		patterns = append(patterns, createEnvDumpPatterns()...)
+	}
+	if opts.IsDependencyConfusion {
+		patterns = append(patterns, createDependencyPatterns()...)
+	}
 
-	wg := sync.WaitGroup{}
-	variableAssignments := make(chan string, 2*len(variableNames))
 	globalCollectedResults := make(map[string]CollectedResult)
-	mutex := &sync.Mutex{}
+	if len(patterns) == 0 {
+		return globalCollectedResults
+	}
+	engine := newSearchEngine(patterns, opts.IsDecode)
+	matches := engine.searchRepoDirectory(owner, repo, threads)
+	if opts.IsSignatures {
+		matches = removeCoveredPrivateKeyMatches(matches)
+	}
+	if opts.IsDependencyConfusion {
+		matches = removeDependencyKeywordMatches(matches)
+	}
 
-	// create worker threads
-	for i := 0; i < threads; i++ {
-		go func(owner, repo string, variableAssignments <-chan string) {
-			for variableAssignment := range variableAssignments {
-				collectedResults := collectSearchResults(owner, repo, variableAssignment)
-				for matchedString, collectedResult := range collectedResults {
-					mutex.Lock()
-					globalCollectedResults[matchedString] = collectedResult
-					mutex.Unlock()
-					if collectedResult.IsCondensed {
-						logger.Print(owner, repo, "\033[1;91mmatched-variable\033[0m", "Found", matchedString, "at",
-							collectedResult.Filename+":"+collectedResult.Line+", with", collectedResult.Occurrences,
-							"similar occurrences (probably randomly generated)")
-					} else {
-						logger.Print(owner, repo, "\033[1;91mmatched-variable\033[0m", "Found", matchedString, "at",
-							collectedResult.Filename+":"+collectedResult.Line+", with", collectedResult.Occurrences,
-							"occurrences in", collectedResult.Files, "files")
-					}
-				}
-				wg.Done()
+	matchesByPattern := make(map[*searchPattern][]searchMatch)
+	for _, match := range matches {
+		matchesByPattern[match.pattern] = append(matchesByPattern[match.pattern], match)
+	}
+	classifier, err := newPlaceholderClassifier(opts.PlaceholdersPath)
+	if err != nil {
+		logger.Print(owner, repo, "search-logs", "Could not read placeholders from", opts.PlaceholdersPath+":", err.Error())
+		classifier, _ = newPlaceholderClassifier("")
+	}
+	filteredReasons := make(map[string]int)
+	for _, pattern := range patterns {
+		if len(matchesByPattern[pattern]) == 0 {
+			continue
+		}
+		collectedResults, filteredResults := collectSearchResults(matchesByPattern[pattern], pattern, classifier, opts)
+		for matchedString, collectedResult := range collectedResults {
+			globalCollectedResults[matchedString] = collectedResult
+			if opts.Suppression.IsSuppressed(matchedString, collectedResult) {
+				continue
 			}
-		}(owner, repo, variableAssignments)
+			logger.Print(owner, repo, "\033[1;91mmatched-"+pattern.kind+"\033[0m", "Found", FormatResult(matchedString, collectedResult))
+		}
+		for matchedString, filteredResult := range filteredResults {
+			filteredReasons[filteredResult.FilterReason]++
+			if opts.IsShowFiltered {
+				logger.Print(owner, repo, "filtered-"+pattern.kind, matchedString, "at", FormatLocation(filteredResult),
+					"is a placeholder ("+filteredResult.FilterReason+")")
+			}
+		}
 	}
-
-	// add variable assignments to channel to trigger workers
-	for _, variableName := range variableNames {
-		wg.Add(1)
-		variableAssignments <- "[^\\ ?&]*" + variableName + "\\ *[:=]\\ *\\([^\\ &]\\+\\)"
+	if len(filteredReasons) > 0 {
+		logger.Print(owner, repo, "search-logs", "Filtered placeholder values:", formatFilteredReasons(filteredReasons))
 	}
 
-	// close channel and wait for threads to finish
-	close(variableAssignments)
-	wg.Wait()
-
 	return globalCollectedResults
 }
 
-func SearchLogsForKeywords(owner, repo, wordlistPath string, threads int) map[string]CollectedResult {
-	keywords := getWordsFromWordlist(wordlistPath)
-	logger.Print(owner, repo, "search-keywords", "Read", len(keywords), "keywords from wordlist")
-
-	wg := sync.WaitGroup{}
-	keywordsChan := make(chan string, len(keywords))
-	globalCollectedResults := make(map[string]CollectedResult)
-	mutex := &sync.Mutex{}
-
-	// create worker threads
-	for i := 0; i < threads; i++ {
-		go func(owner, repo string, keywordsChan <-chan string) {
-			for keyword := range keywordsChan {
-				collectedResults := collectSearchResults(owner, repo, keyword)
-				for matchedString, collectedResult := range collectedResults {
-					mutex.Lock()
-					globalCollectedResults[matchedString] = collectedResult
-					mutex.Unlock()
-					if collectedResult.IsCondensed {
-						logger.Print(owner, repo, "\033[1;91mmatched-keyword\033[0m", "Found", matchedString, "at",
-							collectedResult.Filename+":"+collectedResult.Line+", with", collectedResult.Occurrences,
-							"similar occurrences (probably randomly generated)")
-					} else {
-						logger.Print(owner, repo, "\033[1;91mmatched-keyword\033[0m", "Found", matchedString, "at",
-							collectedResult.Filename+":"+collectedResult.Line+", with", collectedResult.Occurrences,
-							"occurrences in", collectedResult.Files, "files")
-					}
-				}
-				wg.Done()
-			}
-		}(owner, repo, keywordsChan)
+func formatFilteredReasons(filteredReasons map[string]int) string {
+	reasons := []string{}
+	for reason := range filteredReasons {
+		reasons = append(reasons, reason)
 	}
-
-	// add keywords to channel to trigger workers
-	for _, keyword := range keywords {
-		wg.Add(1)
-		keywordsChan <- keyword
+	sort.Strings(reasons)
+	descriptions := []string{}
+	for _, reason := range reasons {
+		descriptions = append(descriptions, reason+" "+strconv.Itoa(filteredReasons[reason]))
 	}
-
-	// close channel and wait for threads to finish
-	close(keywordsChan)
-	wg.Wait()
-
-	return globalCollectedResults
+	return strings.Join(descriptions, ", ")
 }
 
 func getWordsFromWordlist(wordlistPath string) []string {
@@ -145,21 +191,42 @@ func getWordsFromWordlist(wordlistPath string) []string {
 	return words
 }
 
-func collectSearchResults(owner, repo, stringToMatch string) map[string]CollectedResult {
-	grepResults := searchRepoDirectoryUsingGrep(owner, repo, stringToMatch)
+// collectSearchResults condenses the matches of a pattern into results, along with the assignments
+// that were left out because their values are placeholders
+func collectSearchResults(matches []searchMatch, pattern *searchPattern, classifier *placeholderClassifier, opts SearchOptions) (map[string]CollectedResult, map[string]CollectedResult) {
+	isVariableAssignment := pattern.kind == VARIABLE_PATTERN_KIND
+	filteredResults := make(map[string]CollectedResult)
 
-	// condense grep results into files: matchedString, filename => line (first occurrence), occurrences
+	// condense matches into files: matchedString, filename => line (first occurrence), occurrences
 	condensedResultsByFile := make(map[condensedResultByFileKey]condensedResultByFileValue)
-	for _, grepResult := range grepResults {
-
-		// skip censored and blank variable assignments
-		if strings.Contains(stringToMatch, "[:=]") && isVariableAssignmentBlankOrCensored(grepResult.matchedString) {
+	for _, match := range matches {
+
+		// skip assignments of placeholders, keeping the reason so the filter can be checked
+		if isVariableAssignment || pattern.source == MASKED_VARIABLE_RULE || pattern.source == DUMPED_VARIABLE_RULE || pattern.isGeneric {
+			if reason := classifier.classify(getSecretValue(pattern, match.matchedString)); reason != "" {
+				filteredResult, exists := filteredResults[match.matchedString]
+				if !exists {
+					filteredResult = CollectedResult{
+						Filename:     match.filename,
+						Line:         match.line,
+						Job:          match.job,
+						Step:         match.step,
+						Timestamp:    match.timestamp,
+						FilterReason: reason,
+					}
+				}
+				filteredResult.Occurrences++
+				filteredResults[match.matchedString] = filteredResult
+				continue
+			}
+		}
+		if pattern.rule != nil && !pattern.rule.isValueAllowed(getSecretValue(pattern, match.matchedString), match.filename) {
 			continue
 		}
 
 		fileMatchKey := condensedResultByFileKey{
-			filename:      grepResult.filename,
-			matchedString: grepResult.matchedString,
+			filename:      match.filename,
+			matchedString: match.matchedString,
 		}
 		if existingFileMatchOccurrences, exists := condensedResultsByFile[fileMatchKey]; exists {
 			updatedFileMatchOccurrences := existingFileMatchOccurrences
@@ -167,114 +234,93 @@ func collectSearchResults(owner, repo, stringToMatch string) map[string]Collecte
 			condensedResultsByFile[fileMatchKey] = updatedFileMatchOccurrences
 		} else {
 			condensedResultsByFile[fileMatchKey] = condensedResultByFileValue{
-				line:        grepResult.line,
+				line:        match.line,
+				endLine:     match.endLine,
+				encoding:    match.encoding,
+				job:         match.job,
+				step:        match.step,
+				timestamp:   match.timestamp,
 				occurrences: 1,
 			}
 		}
 	}
 
 	// condense file occurrences into global occurrences: matchedString => filename (first occurrence), line (first occurrence), files, occurrences
+	// the first occurrence is the one in the first file by name, so the location and fingerprint of a result are the same every scan
 	collectedResults := make(map[string]CollectedResult)
 	for fileMatch, occurrences := range condensedResultsByFile {
-		if existingCollectedResult, exists := collectedResults[fileMatch.matchedString]; exists {
-			updatedCollectedResult := existingCollectedResult
-			updatedCollectedResult.Files++
-			updatedCollectedResult.Occurrences += occurrences.occurrences
-			collectedResults[fileMatch.matchedString] = updatedCollectedResult
-		} else {
+		existingCollectedResult, exists := collectedResults[fileMatch.matchedString]
+		if !exists || fileMatch.filename < existingCollectedResult.Filename {
 			collectedResults[fileMatch.matchedString] = CollectedResult{
 				Filename:    fileMatch.filename,
 				Line:        occurrences.line,
-				Files:       1,
-				Occurrences: occurrences.occurrences,
-				IsCondensed: false,
+				EndLine:     occurrences.endLine,
+				Encoding:    occurrences.encoding,
+				Job:         occurrences.job,
+				Step:        occurrences.step,
+				Timestamp:   occurrences.timestamp,
+				Files:       existingCollectedResult.Files + 1,
+				Occurrences: existingCollectedResult.Occurrences + occurrences.occurrences,
 			}
+		} else {
+			updatedCollectedResult := existingCollectedResult
+			updatedCollectedResult.Files++
+			updatedCollectedResult.Occurrences += occurrences.occurrences
+			collectedResults[fileMatch.matchedString] = updatedCollectedResult
 		}
 	}
 
-	// if more than n matchedStrings, combine occurrences in only a single file to one log entry as they are probably randomly generated
-	if len(collectedResults) > DUPLICATE_RESULTS_THRESHOLD {
-		matchedStringsToDelete := []string{}
-		firstMatchedString := ""
-		condensedResult := CollectedResult{
-			IsCondensed: true,
+	scoredResults := make(map[string]CollectedResult)
+	for matchedString, result := range collectedResults {
+		switch {
+		case pattern.source == DUMPED_VARIABLE_RULE:
+			result.Confidence = scoreSecretValue(getAssignedValue(matchedString))
+			result.Rule = pattern.source
+		case pattern.isGeneric:
+			result.Confidence = scoreSecretValue(matchedString)
+			result.Rule = pattern.source
+		case pattern.kind == SIGNATURE_PATTERN_KIND || pattern.kind == MULTILINE_PATTERN_KIND || pattern.kind == MASK_LEAK_PATTERN_KIND ||
+			pattern.kind == ENV_DUMP_PATTERN_KIND || pattern.kind == DEPENDENCY_PATTERN_KIND:
+			result.Confidence = 1
+			result.Rule = pattern.source
+			result.Severity = HIGH_SEVERITY
+		case pattern.kind == RULE_PATTERN_KIND:
+			result.Confidence = scoreSecretValue(matchedString)
+		case isVariableAssignment:
+			result.Confidence = scoreSecretValue(getAssignedValue(matchedString))
+		case pattern.kind == KEYWORD_PATTERN_KIND:
+			// keywords are phrases rather than values, so they are always reported
+			result.Confidence = 1
+		default:
+			result.Confidence = scoreSecretValue(matchedString)
 		}
-
-		for matchedString, result := range collectedResults {
-			if result.Files < 2 {
-				matchedStringsToDelete = append(matchedStringsToDelete, matchedString)
-				if firstMatchedString != "" {
-					condensedResult.Occurrences += result.Occurrences
-				} else {
-					firstMatchedString = matchedString
-					condensedResult.Filename = result.Filename
-					condensedResult.Line = result.Line
-					condensedResult.Occurrences = result.Occurrences
-				}
-			}
+		if pattern.kind != KEYWORD_PATTERN_KIND && result.Confidence < opts.MinConfidence {
+			continue
 		}
-
-		for _, matchedStringToDelete := range matchedStringsToDelete {
-			delete(collectedResults, matchedStringToDelete)
+		if pattern.rule != nil && !pattern.rule.isWordlistEntry {
+			result.Rule = pattern.rule.Id
+			result.Severity = pattern.rule.Severity
+			result.Tags = pattern.rule.Tags
 		}
-
-		collectedResults[firstMatchedString] = condensedResult
-	}
-
-	return collectedResults
-}
-
-func searchRepoDirectoryUsingGrep(owner, repo, stringToMatch string) []grepResult {
-	grepResults := []grepResult{}
-	grepOutput, err := exec.Command("grep", "-nrio", stringToMatch, owner+"/"+repo).Output()
-	if err != nil && err.Error() != "exit status 1" {
-		logger.Print(owner, repo, "search-grep", "Could not search for", stringToMatch, "using grep:", err.Error())
-	}
-
-	grepOutputLines := strings.Split(string(grepOutput), "\n")
-	for _, grepOutputLine := range grepOutputLines {
-
-		// split into filename, line number and matched string (separated by colons but can also contain colons)
-		parts := strings.Split(grepOutputLine, ":")
-		if len(parts) < 3 {
-			continue
+		result = addRunToResult(result)
+		result = addContextToResult(result, pattern, matchedString, opts)
+		value := getSecretValue(pattern, matchedString)
+		result.Value = value
+		if value != "" {
+			result.Fingerprint = createFingerprint(getRuleId(pattern), value, result.Filename, result.Run.Workflow)
+		} else {
+			result.Fingerprint = createFingerprint(getRuleId(pattern), strings.ToLower(matchedString), result.Filename, result.Run.Workflow)
 		}
-		partsCount := 0
-		filename := parts[partsCount]
-		partsCount++
 
-		var line string
-		for {
-			if _, err := strconv.Atoi(parts[partsCount]); err != nil {
-				filename += ":" + parts[partsCount]
-				partsCount++
-			} else {
-				line = parts[partsCount]
-				partsCount++
-				break
-			}
+		// results from built-in detectors and rule files are labelled like workflow file results
+		if result.Rule != "" {
+			matchedString = "[" + result.Rule + "] " + matchedString
 		}
-
-		matchedString := strings.TrimSpace(strings.Join(parts[partsCount:], ":"))
-		grepResults = append(grepResults, grepResult{
-			filename:      filename,
-			line:          line,
-			matchedString: matchedString,
-		})
+		if opts.IsRedact {
+			result.RedactedString = redactMatchedString(matchedString, value)
+		}
+		scoredResults[matchedString] = result
 	}
 
-	return grepResults
-}
-
-func isVariableAssignmentBlankOrCensored(matchedString string) bool {
-	if strings.Index(matchedString, ":") == len(matchedString)-1 && !strings.Contains(matchedString, "=") {
-		return true
-	}
-	if strings.Index(matchedString, "=") == len(matchedString)-1 && !strings.Contains(matchedString, ":") {
-		return true
-	}
-	if len(matchedString) >= 3 && matchedString[len(matchedString)-3:] == "***" {
-		return true
-	}
-	return false
+	return scoredResults, filteredResults
 }
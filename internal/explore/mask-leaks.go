@@ -0,0 +1,123 @@
+package explore
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/bm402/gander/internal/actionslog"
+)
+
+var maskedVariableRegex = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\s*[:=]\s*["']?\*\*\*`)
+
+type maskedForm struct {
+	value    string
+	form     string
+	encoding string
+}
+
+func createMaskLeakPatterns() []*searchPattern {
+	return []*searchPattern{
+		{kind: MASK_LEAK_PATTERN_KIND, source: MASKED_VALUE_RULE},
+		{kind: MASK_LEAK_PATTERN_KIND, source: MASKED_VARIABLE_RULE},
+	}
+}
+
+// findMaskLeaks looks through a whole run for values that were masked with ::add-mask:: and
+// variables that were printed as *** in some places, and reports wherever they were printed
+// without the mask, whether that was before the mask was added or in an encoded form
+func (e *searchEngine) findMaskLeaks(run actionslog.Run) []searchMatch {
+	maskedForms := []maskedForm{}
+	maskedVariables := make(map[string]bool)
+	forEachRunLine(run, func(job *actionslog.Job, step *actionslog.Step, line actionslog.Line) {
+		if line.Command == ADD_MASK_COMMAND {
+			if value := strings.TrimSpace(line.Message); len(value) >= MIN_MASKED_VALUE_LENGTH {
+				maskedForms = append(maskedForms, createMaskedForms(value)...)
+			}
+			return
+		}
+		for _, match := range maskedVariableRegex.FindAllStringSubmatch(line.Content, -1) {
+			maskedVariables[match[1]] = true
+		}
+	})
+
+	matches := []searchMatch{}
+	if len(maskedForms) > 0 {
+		matches = append(matches, e.findMaskedValues(run, maskedForms)...)
+	}
+	if len(maskedVariables) > 0 {
+		matches = append(matches, e.findMaskedVariables(run, maskedVariables)...)
+	}
+	return matches
+}
+
+func createMaskedForms(value string) []maskedForm {
+	forms := []maskedForm{{value, value, ""}}
+	encodedForms := []maskedForm{
+		{value, base64.StdEncoding.EncodeToString([]byte(value)), "base64"},
+		{value, base64.StdEncoding.EncodeToString([]byte(value + "\n")), "base64"},
+		{value, hex.EncodeToString([]byte(value)), "hex"},
+		{value, url.QueryEscape(value), "url"},
+	}
+	for _, encodedForm := range encodedForms {
+		if encodedForm.form != value {
+			forms = append(forms, encodedForm)
+		}
+	}
+	return forms
+}
+
+func (e *searchEngine) findMaskedValues(run actionslog.Run, maskedForms []maskedForm) []searchMatch {
+	literals := []string{}
+	for _, maskedForm := range maskedForms {
+		literals = append(literals, maskedForm.form)
+	}
+	automaton := newAhoCorasick(literals)
+
+	matches := []searchMatch{}
+	forEachRunLine(run, func(job *actionslog.Job, step *actionslog.Step, line actionslog.Line) {
+		if line.Command == ADD_MASK_COMMAND {
+			return
+		}
+		found := make(map[int]bool)
+		automaton.findAll(line.Content, func(idx int) {
+			// the automaton ignores case, but encoded values do not
+			if found[idx] || !strings.Contains(line.Content, maskedForms[idx].form) {
+				return
+			}
+			found[idx] = true
+			match := createStepMatch(findPatternBySource(e.maskLeaks, MASKED_VALUE_RULE), job, step, line, maskedForms[idx].value)
+			match.encoding = maskedForms[idx].encoding
+			matches = append(matches, match)
+		})
+	})
+	return matches
+}
+
+func (e *searchEngine) findMaskedVariables(run actionslog.Run, maskedVariables map[string]bool) []searchMatch {
+	names := []string{}
+	for name := range maskedVariables {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	assignmentRegex := regexp.MustCompile(`\b(` + strings.Join(names, "|") + `)\s*[:=]\s*["']?([^\s"'*][^\s"']*)`)
+
+	matches := []searchMatch{}
+	forEachRunLine(run, func(job *actionslog.Job, step *actionslog.Step, line actionslog.Line) {
+		for _, match := range assignmentRegex.FindAllStringSubmatch(line.Content, -1) {
+			matches = append(matches, createStepMatch(findPatternBySource(e.maskLeaks, MASKED_VARIABLE_RULE), job, step, line, match[1]+"="+match[2]))
+		}
+	})
+	return matches
+}
+
+func forEachRunLine(run actionslog.Run, callback func(job *actionslog.Job, step *actionslog.Step, line actionslog.Line)) {
+	for _, job := range run.Jobs {
+		for _, step := range job.Steps {
+			for _, line := range step.Lines {
+				callback(job, step, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package explore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var logTimestampRegex = regexp.MustCompile(`^\x{feff}?\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z `)
+var pemBeginRegex = regexp.MustCompile(`-----BEGIN ((?:[A-Z0-9]+ )*PRIVATE KEY)-----`)
+var pemEndRegex = regexp.MustCompile(`-----END ((?:[A-Z0-9]+ )*PRIVATE KEY)-----`)
+var kubeconfigStartRegex = regexp.MustCompile(`^\s*(apiVersion:\s*v1|kind:\s*Config)\s*$`)
+
+type multilineFinding struct {
+	matchedString string
+	startLine     int
+	endLine       int
+}
+
+// multilineDetector is given every line of a file in order and reports the blocks of lines that
+// make up one secret, which the line by line patterns only see fragments of
+type multilineDetector interface {
+	addLine(text string, lineNumber int) []multilineFinding
+	end() []multilineFinding
+}
+
+type multilineDefinition struct {
+	name        string
+	newDetector func() multilineDetector
+}
+
+var multilineDefinitions = []multilineDefinition{
+	{PRIVATE_KEY_BLOCK_RULE, func() multilineDetector { return &pemDetector{} }},
+	{"gcp-service-account", func() multilineDetector { return &serviceAccountDetector{} }},
+	{"kubeconfig", func() multilineDetector { return &kubeconfigDetector{} }},
+}
+
+func createMultilinePatterns() []*searchPattern {
+	patterns := []*searchPattern{}
+	for _, definition := range multilineDefinitions {
+		patterns = append(patterns, &searchPattern{
+			kind:        MULTILINE_PATTERN_KIND,
+			source:      definition.name,
+			newDetector: definition.newDetector,
+		})
+	}
+	return patterns
+}
+
+// actions logs start every line with a timestamp, which is not part of what was printed
+func stripLogTimestamp(text string) string {
+	return logTimestampRegex.ReplaceAllString(text, "")
+}
+
+// lineBlock collects consecutive lines, remembering where they started
+type lineBlock struct {
+	lines     []string
+	startLine int
+}
+
+func (b *lineBlock) isOpen() bool {
+	return b.lines != nil
+}
+
+func (b *lineBlock) open(text string, lineNumber int) {
+	b.lines = []string{text}
+	b.startLine = lineNumber
+}
+
+func (b *lineBlock) close() {
+	b.lines = nil
+}
+
+func (b *lineBlock) isFull() bool {
+	return len(b.lines) >= MAX_MULTILINE_BLOCK_LINES
+}
+
+type pemDetector struct {
+	block lineBlock
+	label string
+}
+
+func (d *pemDetector) addLine(text string, lineNumber int) []multilineFinding {
+	text = strings.TrimSpace(stripLogTimestamp(text))
+	if begin := pemBeginRegex.FindStringSubmatch(text); begin != nil {
+		d.block.open(text, lineNumber)
+		d.label = begin[1]
+		return nil
+	}
+	if !d.block.isOpen() {
+		return nil
+	}
+	if end := pemEndRegex.FindStringSubmatch(text); end != nil {
+		findings := []multilineFinding{}
+		if end[1] == d.label && len(d.block.lines) > 1 {
+			findings = append(findings, multilineFinding{
+				matchedString: describePemBlock(d.label, d.block.lines[1:]),
+				startLine:     d.block.startLine,
+				endLine:       lineNumber,
+			})
+		}
+		d.block.close()
+		return findings
+	}
+	if d.block.isFull() {
+		d.block.close()
+		return nil
+	}
+	d.block.lines = append(d.block.lines, text)
+	return nil
+}
+
+func (d *pemDetector) end() []multilineFinding {
+	return nil
+}
+
+// the body is hashed so that the same key is recognised across files without printing any of it
+func describePemBlock(label string, bodyLines []string) string {
+	body := ""
+	for _, line := range bodyLines {
+		// skip headers such as Proc-Type in encrypted keys
+		if !strings.Contains(line, ":") {
+			body += line
+		}
+	}
+	hash := sha256.Sum256([]byte(body))
+	return label + " " + hex.EncodeToString(hash[:])[:PEM_BODY_HASH_LENGTH]
+}
+
+// the header of a private key block is also matched by the private-key signature, which is left
+// out where a block was found so the key is only reported once
+func removeCoveredPrivateKeyMatches(matches []searchMatch) []searchMatch {
+	blockStarts := make(map[matchLocation]bool)
+	for _, match := range matches {
+		if match.pattern.source == PRIVATE_KEY_BLOCK_RULE {
+			blockStarts[matchLocation{match.filename, match.line}] = true
+		}
+	}
+	remainingMatches := []searchMatch{}
+	for _, match := range matches {
+		if match.pattern.source == PRIVATE_KEY_RULE && blockStarts[matchLocation{match.filename, match.line}] {
+			continue
+		}
+		remainingMatches = append(remainingMatches, match)
+	}
+	return remainingMatches
+}
+
+type serviceAccountDetector struct {
+	block lineBlock
+	depth int
+}
+
+type serviceAccount struct {
+	Type         string `json:"type"`
+	ProjectId    string `json:"project_id"`
+	PrivateKeyId string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+}
+
+func (d *serviceAccountDetector) addLine(text string, lineNumber int) []multilineFinding {
+	text = stripLogTimestamp(text)
+	if !d.block.isOpen() {
+		if !strings.HasPrefix(strings.TrimSpace(text), "{") {
+			return nil
+		}
+		d.block.open(text, lineNumber)
+		d.depth = 0
+	} else {
+		d.block.lines = append(d.block.lines, text)
+	}
+
+	d.depth += strings.Count(text, "{") - strings.Count(text, "}")
+	if d.depth > 0 {
+		if d.block.isFull() {
+			d.block.close()
+		}
+		return nil
+	}
+
+	findings := []multilineFinding{}
+	account := serviceAccount{}
+	err := json.Unmarshal([]byte(strings.Join(d.block.lines, "\n")), &account)
+	if err == nil && account.Type == "service_account" && account.PrivateKey != "" {
+		findings = append(findings, multilineFinding{
+			matchedString: "service account " + account.ClientEmail + " key " + account.PrivateKeyId,
+			startLine:     d.block.startLine,
+			endLine:       lineNumber,
+		})
+	}
+	d.block.close()
+	return findings
+}
+
+func (d *serviceAccountDetector) end() []multilineFinding {
+	return nil
+}
+
+type kubeconfigDetector struct {
+	block   lineBlock
+	endLine int
+}
+
+type kubeconfig struct {
+	Kind     string `yaml:"kind"`
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server string `yaml:"server"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token         string `yaml:"token"`
+			Password      string `yaml:"password"`
+			ClientKeyData string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+func (d *kubeconfigDetector) addLine(text string, lineNumber int) []multilineFinding {
+	text = stripLogTimestamp(text)
+	if !d.block.isOpen() {
+		if kubeconfigStartRegex.MatchString(text) {
+			d.block.open(text, lineNumber)
+			d.endLine = lineNumber
+		}
+		return nil
+	}
+
+	// the document carries on while lines look like yaml
+	trimmed := strings.TrimSpace(text)
+	isYaml := trimmed == "" || strings.HasPrefix(trimmed, "-") || strings.HasPrefix(trimmed, "#") ||
+		strings.Contains(trimmed, ":") || strings.HasPrefix(text, " ")
+	if isYaml && !d.block.isFull() {
+		d.block.lines = append(d.block.lines, text)
+		if trimmed != "" {
+			d.endLine = lineNumber
+		}
+		return nil
+	}
+
+	findings := d.end()
+	if kubeconfigStartRegex.MatchString(text) {
+		d.block.open(text, lineNumber)
+		d.endLine = lineNumber
+	}
+	return findings
+}
+
+func (d *kubeconfigDetector) end() []multilineFinding {
+	if !d.block.isOpen() {
+		return nil
+	}
+	defer d.block.close()
+
+	config := kubeconfig{}
+	err := yaml.Unmarshal([]byte(strings.Join(d.block.lines, "\n")), &config)
+	if err != nil || config.Kind != "Config" {
+		return nil
+	}
+	findings := []multilineFinding{}
+	for _, user := range config.Users {
+		if user.User.Token == "" && user.User.Password == "" && user.User.ClientKeyData == "" {
+			continue
+		}
+		matchedString := "kubeconfig user " + user.Name
+		if len(config.Clusters) > 0 {
+			matchedString += " for " + config.Clusters[0].Cluster.Server
+		}
+		findings = append(findings, multilineFinding{
+			matchedString: matchedString,
+			startLine:     d.block.startLine,
+			endLine:       d.endLine,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,74 @@
+package explore
+
+import (
+	"io/ioutil"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+type placeholderShape struct {
+	Reason string `yaml:"reason"`
+	Regex  string `yaml:"regex"`
+	regex  *regexp.Regexp
+}
+
+type placeholdersFile struct {
+	Placeholders []placeholderShape `yaml:"placeholders"`
+}
+
+// values that are assigned in logs are often references or placeholders rather than secrets
+var builtinPlaceholderShapes = []placeholderShape{
+	{Reason: "blank", Regex: `^$`},
+	{Reason: "censored", Regex: `^\*{3,}$|\*\*\*$`},
+	{Reason: "actions-expression", Regex: `^\$\{\{`},
+	{Reason: "shell-variable", Regex: `^\$\{?[A-Za-z_][A-Za-z0-9_]*\}?(/.*)?$|^\$\(|^%[A-Za-z_][A-Za-z0-9_]*%$`},
+	{Reason: "angle-bracket-placeholder", Regex: `^<[^<>]*>$`},
+	{Reason: "redacted-word", Regex: `(?i)^\[?(redacted|hidden|masked|removed|secret|changeme|change_me|placeholder|dummy|example|sample|your[_-]?[a-z_-]*(here)?)\]?$`},
+	{Reason: "repeated-character", Regex: `^(x+|X+|0+|1+|\.+|-+|#+|_+)$`},
+	{Reason: "null", Regex: `(?i)^(null|nil|none|undefined|empty|n/a)$`},
+	{Reason: "boolean", Regex: `(?i)^(true|false|yes|no|on|off)$`},
+	{Reason: "format-placeholder", Regex: `^%[-+ #0-9.]*[sdvqfxXp]$|^\{\d*\}$|^\{\{.*\}\}$`},
+}
+
+type placeholderClassifier struct {
+	shapes []placeholderShape
+}
+
+// newPlaceholderClassifier uses the built-in shapes, followed by any from a yaml file
+func newPlaceholderClassifier(placeholdersPath string) (*placeholderClassifier, error) {
+	shapes := append([]placeholderShape{}, builtinPlaceholderShapes...)
+	if placeholdersPath != "" {
+		contents, err := ioutil.ReadFile(placeholdersPath)
+		if err != nil {
+			return nil, err
+		}
+		file := placeholdersFile{}
+		err = yaml.Unmarshal(contents, &file)
+		if err != nil {
+			return nil, err
+		}
+		shapes = append(shapes, file.Placeholders...)
+	}
+
+	classifier := &placeholderClassifier{}
+	for _, shape := range shapes {
+		regex, err := regexp.Compile(shape.Regex)
+		if err != nil {
+			return nil, err
+		}
+		shape.regex = regex
+		classifier.shapes = append(classifier.shapes, shape)
+	}
+	return classifier, nil
+}
+
+// classify returns the reason a value is a placeholder, or an empty string if it could be a secret
+func (c *placeholderClassifier) classify(value string) string {
+	for _, shape := range c.shapes {
+		if shape.regex.MatchString(value) {
+			return shape.Reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package explore
+
+import (
+	"errors"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/bm402/gander/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule describes what to search for with one of a regex, a variable name to find assignments to,
+// or a keyword, along with the constraints a matched value has to meet to be reported
+type Rule struct {
+	Id          string   `yaml:"id"`
+	Description string   `yaml:"description"`
+	Severity    string   `yaml:"severity"`
+	Tags        []string `yaml:"tags"`
+	Regex       string   `yaml:"regex"`
+	Variable    string   `yaml:"variable"`
+	Keyword     string   `yaml:"keyword"`
+	MinLength   int      `yaml:"min_length"`
+	Charset     string   `yaml:"charset"`
+	MinEntropy  float64  `yaml:"min_entropy"`
+	AllowValues []string `yaml:"allow_values"`
+	AllowPaths  []string `yaml:"allow_paths"`
+
+	isWordlistEntry   bool
+	charsetRegex      *regexp.Regexp
+	allowValueRegexes []*regexp.Regexp
+	allowPathRegexes  []*regexp.Regexp
+}
+
+type rulesFile struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+func readRulesFile(rulesPath string) ([]*Rule, error) {
+	contents, err := ioutil.ReadFile(rulesPath)
+	if err != nil {
+		return nil, err
+	}
+	file := rulesFile{}
+	err = yaml.Unmarshal(contents, &file)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []*Rule{}
+	for idx := range file.Rules {
+		rule := &file.Rules[idx]
+		err = rule.compile()
+		if err != nil {
+			return nil, errors.New("rule " + rule.Id + ": " + err.Error())
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// the plain text wordlists are read as rules with nothing but a variable name or keyword
+func createRulesFromWordlist(kind string, words []string) []*Rule {
+	rules := []*Rule{}
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		rule := &Rule{
+			Id:              kind + ":" + word,
+			isWordlistEntry: true,
+		}
+		if kind == VARIABLE_PATTERN_KIND {
+			rule.Variable = word
+		} else {
+			rule.Keyword = word
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (r *Rule) compile() error {
+	kinds := 0
+	for _, pattern := range []string{r.Regex, r.Variable, r.Keyword} {
+		if pattern != "" {
+			kinds++
+		}
+	}
+	if r.Id == "" {
+		return errors.New("no id")
+	}
+	if kinds != 1 {
+		return errors.New("needs exactly one of regex, variable or keyword")
+	}
+
+	var err error
+	if r.Charset != "" {
+		if r.charsetRegex, err = regexp.Compile("^" + r.Charset + "+$"); err != nil {
+			return err
+		}
+	}
+	for _, allowValue := range r.AllowValues {
+		allowValueRegex, err := regexp.Compile(allowValue)
+		if err != nil {
+			return err
+		}
+		r.allowValueRegexes = append(r.allowValueRegexes, allowValueRegex)
+	}
+	for _, allowPath := range r.AllowPaths {
+		allowPathRegex, err := regexp.Compile(allowPath)
+		if err != nil {
+			return err
+		}
+		r.allowPathRegexes = append(r.allowPathRegexes, allowPathRegex)
+	}
+	return nil
+}
+
+func createPatternsFromRules(owner, repo string, rules []*Rule) []*searchPattern {
+	patterns := []*searchPattern{}
+	for _, rule := range rules {
+		pattern, err := createPatternFromRule(rule)
+		if err != nil {
+			logger.Print(owner, repo, "search-logs", "Could not compile", rule.Id+":", err.Error())
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+func createPatternFromRule(rule *Rule) (*searchPattern, error) {
+	var pattern *searchPattern
+	var err error
+	switch {
+	case rule.Variable != "":
+		bre := "[^\\ ?&]*" + rule.Variable + "\\ *[:=]\\ *\\([^\\ &]\\+\\)"
+		pattern, err = newSearchPattern(VARIABLE_PATTERN_KIND, rule.Variable, bre, findRequiredLiteral(rule.Variable))
+	case rule.Keyword != "":
+		pattern, err = newSearchPattern(KEYWORD_PATTERN_KIND, rule.Keyword, rule.Keyword, findRequiredLiteral(rule.Keyword))
+	default:
+		// regex rules are re2 and case sensitive, so only a literal prefix can narrow down the lines
+		regex, compileErr := regexp.Compile(rule.Regex)
+		if compileErr != nil {
+			return nil, compileErr
+		}
+		literal, _ := regex.LiteralPrefix()
+		pattern = &searchPattern{
+			kind:    RULE_PATTERN_KIND,
+			source:  rule.Id,
+			literal: strings.ToLower(literal),
+			regex:   regex,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	pattern.rule = rule
+	return pattern, nil
+}
+
+// isValueAllowed applies the constraints and false positive filters of a rule to a matched value
+func (r *Rule) isValueAllowed(value, filename string) bool {
+	if len(value) < r.MinLength {
+		return false
+	}
+	if r.charsetRegex != nil && !r.charsetRegex.MatchString(value) {
+		return false
+	}
+	if r.MinEntropy > 0 && shannonEntropy(value) < r.MinEntropy {
+		return false
+	}
+	for _, allowValueRegex := range r.allowValueRegexes {
+		if allowValueRegex.MatchString(value) {
+			return false
+		}
+	}
+	for _, allowPathRegex := range r.allowPathRegexes {
+		if allowPathRegex.MatchString(filename) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,26 @@
+package explore
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/bm402/gander/internal/metadata"
+)
+
+// each downloaded run folder has a run file with the details of the run that produced the logs
+func addRunToResult(result CollectedResult) CollectedResult {
+	run, err := metadata.ReadRun(getRunFolder(result.Filename))
+	if err == nil {
+		result.Run = run
+	}
+	return result
+}
+
+// results are under owner/repo/run, but files from artifacts can be nested further down
+func getRunFolder(filename string) string {
+	parts := strings.Split(filepath.ToSlash(filename), "/")
+	if len(parts) < 4 {
+		return filepath.Dir(filename)
+	}
+	return filepath.Join(parts[:3]...)
+}
@@ -0,0 +1,69 @@
+package explore
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// scoreSecretValue estimates how likely a value is to be a secret from 0 to 1. Random tokens have high
+// entropy, mix several classes of characters and tend to be long, whereas versions, flags and words
+// do not
+func scoreSecretValue(value string) float64 {
+	if len(value) == 0 {
+		return 0
+	}
+	entropyScore := math.Min(shannonEntropy(value)/MAX_ENTROPY_SCORE, 1)
+	classScore := float64(countCharacterClasses(value)) / 4
+	lengthScore := math.Min(float64(len(value))/float64(MAX_LENGTH_SCORE), 1)
+	score := 0.5*entropyScore + 0.25*classScore + 0.25*lengthScore
+	return math.Round(score*100) / 100
+}
+
+// shannonEntropy returns the number of bits of information per character in the value
+func shannonEntropy(value string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		probability := float64(count) / float64(total)
+		entropy -= probability * math.Log2(probability)
+	}
+	return entropy
+}
+
+func countCharacterClasses(value string) int {
+	hasLower, hasUpper, hasDigit, hasSymbol := false, false, false, false
+	for _, r := range value {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	classes := 0
+	for _, hasClass := range []bool{hasLower, hasUpper, hasDigit, hasSymbol} {
+		if hasClass {
+			classes++
+		}
+	}
+	return classes
+}
+
+// getAssignedValue returns the value of a variable assignment such as NAME=value or NAME: value
+func getAssignedValue(matchedString string) string {
+	idx := strings.IndexAny(matchedString, ":=")
+	if idx < 0 {
+		return matchedString
+	}
+	return strings.Trim(strings.TrimSpace(matchedString[idx+1:]), "\"'")
+}
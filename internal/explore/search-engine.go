@@ -0,0 +1,346 @@
+package explore
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bm402/gander/internal/actionslog"
+	"github.com/bm402/gander/internal/logger"
+)
+
+type searchPattern struct {
+	kind        string
+	source      string
+	literal     string
+	regex       *regexp.Regexp
+	newDetector func() multilineDetector
+	rule        *Rule
+	isGeneric   bool
+}
+
+type searchMatch struct {
+	pattern       *searchPattern
+	filename      string
+	line          string
+	endLine       string
+	encoding      string
+	job           string
+	step          string
+	timestamp     time.Time
+	matchedString string
+}
+
+// searchEngine matches every pattern against each line in one pass, using an automaton over the
+// literals the patterns require so that a pattern's regex only runs on lines that could match it
+type searchEngine struct {
+	patterns   []*searchPattern
+	automaton  *ahoCorasick
+	unfiltered []*searchPattern
+	literalIds []int
+	multiline  []*searchPattern
+	maskLeaks  []*searchPattern
+	envDumps   []*searchPattern
+	isDecode   bool
+}
+
+func newSearchPattern(kind, source, pattern, literal string) (*searchPattern, error) {
+	regex, err := regexp.Compile("(?i)" + convertBreToRegex(pattern))
+	if err != nil {
+		return nil, err
+	}
+	return &searchPattern{
+		kind:    kind,
+		source:  source,
+		literal: literal,
+		regex:   regex,
+	}, nil
+}
+
+func newSearchEngine(patterns []*searchPattern, isDecode bool) *searchEngine {
+	engine := &searchEngine{
+		patterns: patterns,
+		isDecode: isDecode,
+	}
+	literals := []string{}
+	for idx, pattern := range patterns {
+		if pattern.newDetector != nil {
+			engine.multiline = append(engine.multiline, pattern)
+			continue
+		}
+		if pattern.kind == MASK_LEAK_PATTERN_KIND {
+			engine.maskLeaks = append(engine.maskLeaks, pattern)
+			continue
+		}
+		if pattern.kind == ENV_DUMP_PATTERN_KIND {
+			engine.envDumps = append(engine.envDumps, pattern)
+			continue
+		}
+		if pattern.literal == "" {
+			engine.unfiltered = append(engine.unfiltered, pattern)
+			continue
+		}
+		literals = append(literals, pattern.literal)
+		engine.literalIds = append(engine.literalIds, idx)
+	}
+	engine.automaton = newAhoCorasick(literals)
+	return engine
+}
+
+// runs are searched one folder at a time, so the lines of their logs are known by job and step
+func (e *searchEngine) searchRepoDirectory(owner, repo string, threads int) []searchMatch {
+	entries, err := ioutil.ReadDir(filepath.Join(owner, repo))
+	if err != nil {
+		logger.Print(owner, repo, "search-logs", "Could not list log folders:", err.Error())
+	}
+
+	wg := sync.WaitGroup{}
+	pathsChan := make(chan string, len(entries))
+	matches := []searchMatch{}
+	mutex := &sync.Mutex{}
+
+	// create worker threads
+	for i := 0; i < threads; i++ {
+		go func(pathsChan <-chan string) {
+			for path := range pathsChan {
+				var pathMatches []searchMatch
+				var err error
+				if info, statErr := os.Stat(path); statErr == nil && info.IsDir() {
+					pathMatches, err = e.searchRunFolder(path)
+				} else {
+					pathMatches, err = e.searchFile(path)
+				}
+				if err != nil {
+					logger.Print(owner, repo, "search-logs", "Could not search", path+":", err.Error())
+				}
+				mutex.Lock()
+				matches = append(matches, pathMatches...)
+				mutex.Unlock()
+				wg.Done()
+			}
+		}(pathsChan)
+	}
+
+	// add run folders to channel to trigger workers
+	for _, entry := range entries {
+		wg.Add(1)
+		pathsChan <- filepath.Join(owner, repo, entry.Name())
+	}
+
+	// close channel and wait for threads to finish
+	close(pathsChan)
+	wg.Wait()
+
+	return matches
+}
+
+func (e *searchEngine) searchRunFolder(folderPath string) ([]searchMatch, error) {
+	run, err := actionslog.ParseRunFolder(folderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []searchMatch{}
+	for _, job := range run.Jobs {
+		for _, step := range job.Steps {
+			searcher := e.newLineSearcher()
+			stepMatches := []searchMatch{}
+			for _, line := range step.Lines {
+				for _, match := range searcher.searchLine(line.Content, line.Number) {
+					match.filename = line.Filename
+					match.timestamp = line.Timestamp
+					stepMatches = append(stepMatches, match)
+				}
+			}
+			for _, match := range searcher.end() {
+				match.filename = step.Lines[len(step.Lines)-1].Filename
+				stepMatches = append(stepMatches, match)
+			}
+			for _, match := range stepMatches {
+				match.job = job.Name
+				match.step = actionslog.FormatStep(step)
+				matches = append(matches, match)
+			}
+		}
+	}
+
+	if len(e.maskLeaks) > 0 {
+		matches = append(matches, e.findMaskLeaks(run)...)
+	}
+	if len(e.envDumps) > 0 {
+		matches = append(matches, e.findEnvironmentDumps(run)...)
+	}
+
+	for _, filename := range run.OtherFiles {
+		fileMatches, err := e.searchFile(filename)
+		if err != nil {
+			return matches, err
+		}
+		matches = append(matches, fileMatches...)
+	}
+	return matches, nil
+}
+
+func (e *searchEngine) searchFile(filename string) ([]searchMatch, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// skip binary files like grep does
+	reader := bufio.NewReader(file)
+	if head, _ := reader.Peek(actionslog.BINARY_CHECK_LENGTH); bytes.IndexByte(head, 0) >= 0 {
+		return []searchMatch{}, nil
+	}
+
+	matches := []searchMatch{}
+	searcher := e.newLineSearcher()
+	for lineNumber := 1; ; lineNumber++ {
+		text, err := reader.ReadString('\n')
+		if len(text) > 0 {
+			for _, match := range searcher.searchLine(strings.TrimRight(text, "\r\n"), lineNumber) {
+				match.filename = filename
+				matches = append(matches, match)
+			}
+		}
+		if err == io.EOF {
+			for _, match := range searcher.end() {
+				match.filename = filename
+				matches = append(matches, match)
+			}
+			return matches, nil
+		} else if err != nil {
+			return matches, err
+		}
+	}
+}
+
+// lineSearcher searches the lines of one log in order, keeping the state of the multiline detectors
+type lineSearcher struct {
+	engine    *searchEngine
+	seen      []bool
+	detectors []multilineDetector
+}
+
+func (e *searchEngine) newLineSearcher() *lineSearcher {
+	searcher := &lineSearcher{
+		engine: e,
+		seen:   make([]bool, len(e.patterns)),
+	}
+	for _, pattern := range e.multiline {
+		searcher.detectors = append(searcher.detectors, pattern.newDetector())
+	}
+	return searcher
+}
+
+func (s *lineSearcher) searchLine(text string, lineNumber int) []searchMatch {
+	matches := s.engine.searchLine(text, s.seen)
+	if s.engine.isDecode {
+		matches = append(matches, s.engine.searchEncoded(text, s.seen, "", 1)...)
+	}
+	for idx := range matches {
+		matches[idx].line = strconv.Itoa(lineNumber)
+	}
+	for idx, detector := range s.detectors {
+		matches = append(matches, createMultilineMatches(s.engine.multiline[idx], detector.addLine(text, lineNumber))...)
+	}
+	return matches
+}
+
+func (s *lineSearcher) end() []searchMatch {
+	matches := []searchMatch{}
+	for idx, detector := range s.detectors {
+		matches = append(matches, createMultilineMatches(s.engine.multiline[idx], detector.end())...)
+	}
+	return matches
+}
+
+func createMultilineMatches(pattern *searchPattern, findings []multilineFinding) []searchMatch {
+	matches := []searchMatch{}
+	for _, finding := range findings {
+		matches = append(matches, searchMatch{
+			pattern:       pattern,
+			line:          strconv.Itoa(finding.startLine),
+			endLine:       strconv.Itoa(finding.endLine),
+			matchedString: finding.matchedString,
+		})
+	}
+	return matches
+}
+
+// createStepMatch creates a match for the analyzers that look at a whole run rather than one line
+func createStepMatch(pattern *searchPattern, job *actionslog.Job, step *actionslog.Step, line actionslog.Line, matchedString string) searchMatch {
+	return searchMatch{
+		pattern:       pattern,
+		filename:      line.Filename,
+		line:          strconv.Itoa(line.Number),
+		job:           job.Name,
+		step:          actionslog.FormatStep(step),
+		timestamp:     line.Timestamp,
+		matchedString: matchedString,
+	}
+}
+
+func findPatternBySource(patterns []*searchPattern, source string) *searchPattern {
+	for _, pattern := range patterns {
+		if pattern.source == source {
+			return pattern
+		}
+	}
+	return nil
+}
+
+func (e *searchEngine) searchLine(text string, seen []bool) []searchMatch {
+	// a literal can occur many times in a line, but each pattern only needs to run once
+	candidateIds := []int{}
+	e.automaton.findAll(text, func(idx int) {
+		patternIdx := e.literalIds[idx]
+		if !seen[patternIdx] {
+			seen[patternIdx] = true
+			candidateIds = append(candidateIds, patternIdx)
+		}
+	})
+	candidates := []*searchPattern{}
+	for _, patternIdx := range candidateIds {
+		seen[patternIdx] = false
+		candidates = append(candidates, e.patterns[patternIdx])
+	}
+	candidates = append(candidates, e.unfiltered...)
+
+	matches := []searchMatch{}
+	for _, pattern := range candidates {
+		for _, matchedString := range findAllMatchedStrings(pattern, text) {
+			matchedString = strings.TrimSpace(matchedString)
+			if matchedString == "" {
+				continue
+			}
+			matches = append(matches, searchMatch{
+				pattern:       pattern,
+				matchedString: matchedString,
+			})
+		}
+	}
+	return matches
+}
+
+// rule, dependency and generic signature regexes with a group report the group, so the regex can
+// match what surrounds it
+func findAllMatchedStrings(pattern *searchPattern, text string) []string {
+	if (pattern.kind != RULE_PATTERN_KIND && pattern.kind != DEPENDENCY_PATTERN_KIND && !pattern.isGeneric) || pattern.regex.NumSubexp() == 0 {
+		return pattern.regex.FindAllString(text, -1)
+	}
+	matchedStrings := []string{}
+	for _, submatches := range pattern.regex.FindAllStringSubmatch(text, -1) {
+		matchedStrings = append(matchedStrings, submatches[1])
+	}
+	return matchedStrings
+}
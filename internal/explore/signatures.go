@@ -0,0 +1,57 @@
+package explore
+
+import (
+	"regexp"
+)
+
+type signature struct {
+	name    string
+	literal string
+	regex   string
+}
+
+// signatures match secrets with a well-known shape, so they are found without a variable name and
+// reported with full confidence
+var signatures = []signature{
+	{"aws-access-key-id", "akia", `\bAKIA[0-9A-Z]{16}\b`},
+	{"aws-temporary-access-key-id", "asia", `\bASIA[0-9A-Z]{16}\b`},
+	{"github-personal-access-token", "ghp_", `\bghp_[A-Za-z0-9]{36}\b`},
+	{"github-oauth-token", "gho_", `\bgho_[A-Za-z0-9]{36}\b`},
+	{"github-user-to-server-token", "ghu_", `\bghu_[A-Za-z0-9]{36}\b`},
+	{"github-server-to-server-token", "ghs_", `\bghs_[A-Za-z0-9]{36}\b`},
+	{"github-refresh-token", "ghr_", `\bghr_[A-Za-z0-9]{36,76}\b`},
+	{"github-fine-grained-token", "github_pat_", `\bgithub_pat_[A-Za-z0-9_]{82}\b`},
+	{"slack-token", "xox", `\bxox[abposr]-[A-Za-z0-9-]{10,}`},
+	{"slack-webhook", "hooks.slack.com/services/", `https://hooks\.slack\.com/services/T[A-Z0-9]+/B[A-Z0-9]+/[A-Za-z0-9]+`},
+	{"stripe-secret-key", "k_live_", `\b[rs]k_live_[A-Za-z0-9]{24,}\b`},
+	{"google-api-key", "aiza", `\bAIza[0-9A-Za-z_\-]{35}`},
+	{PRIVATE_KEY_RULE, "private key-----", `-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----`},
+}
+
+// generic signatures match how any secret is passed rather than the secret itself, so the group
+// they capture is scored like a rule
+var genericSignatures = []signature{
+	{"bearer-token", "bearer", `(?i)\bbearer\s+([A-Za-z0-9\-._~+/]{16,}=*)`},
+	{"basic-auth-url", "://", `\b[A-Za-z][A-Za-z0-9+.-]*://[^:@/\s]+:([^@/\s]+)@`},
+}
+
+func createSignaturePatterns() []*searchPattern {
+	patterns := []*searchPattern{}
+	for _, signature := range signatures {
+		patterns = append(patterns, createSignaturePattern(signature, false))
+	}
+	for _, signature := range genericSignatures {
+		patterns = append(patterns, createSignaturePattern(signature, true))
+	}
+	return patterns
+}
+
+func createSignaturePattern(signature signature, isGeneric bool) *searchPattern {
+	return &searchPattern{
+		kind:      SIGNATURE_PATTERN_KIND,
+		source:    signature.name,
+		literal:   signature.literal,
+		regex:     regexp.MustCompile(signature.regex),
+		isGeneric: isGeneric,
+	}
+}
@@ -0,0 +1,191 @@
+package explore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bm402/gander/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// baseline entries only locate a finding, since the baseline is meant to be committed and the
+// fingerprint is there so that the secret is not
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Repo        string `json:"repo"`
+	Rule        string `json:"rule,omitempty"`
+	Location    string `json:"location"`
+}
+
+type baselineFile struct {
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// allowlist rules match when every field they give matches, so a rule can be narrowed to one repo
+type allowlistRule struct {
+	Description string `yaml:"description"`
+	Value       string `yaml:"value"`
+	Path        string `yaml:"path"`
+	Repo        string `yaml:"repo"`
+	valueRegex  *regexp.Regexp
+	pathRegex   *regexp.Regexp
+}
+
+// Suppression hides findings that are in a baseline from an earlier scan or match the allowlist,
+// and records everything it lets through so that a new baseline can be written
+type Suppression struct {
+	baseline  map[string]BaselineEntry
+	allowlist []allowlistRule
+	found     map[string]BaselineEntry
+}
+
+func LoadSuppression(baselinePath, allowlistPath string) (*Suppression, error) {
+	suppression := &Suppression{
+		baseline:  make(map[string]BaselineEntry),
+		allowlist: []allowlistRule{},
+		found:     make(map[string]BaselineEntry),
+	}
+	if baselinePath != "" {
+		err := suppression.readBaseline(baselinePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if allowlistPath != "" {
+		err := suppression.readAllowlist(allowlistPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return suppression, nil
+}
+
+func (s *Suppression) readBaseline(baselinePath string) error {
+	contents, err := ioutil.ReadFile(baselinePath)
+	if os.IsNotExist(err) {
+		// the first scan creates the baseline
+		return nil
+	} else if err != nil {
+		return err
+	}
+	baseline := baselineFile{}
+	err = json.Unmarshal(contents, &baseline)
+	if err != nil {
+		return err
+	}
+	for _, entry := range baseline.Findings {
+		s.baseline[entry.Fingerprint] = entry
+	}
+	return nil
+}
+
+func (s *Suppression) readAllowlist(allowlistPath string) error {
+	contents, err := ioutil.ReadFile(allowlistPath)
+	if err != nil {
+		return err
+	}
+	rules := []allowlistRule{}
+	err = yaml.Unmarshal(contents, &rules)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.Value != "" {
+			if rule.valueRegex, err = regexp.Compile(rule.Value); err != nil {
+				return err
+			}
+		}
+		if rule.Path != "" {
+			if rule.pathRegex, err = regexp.Compile(rule.Path); err != nil {
+				return err
+			}
+		}
+		s.allowlist = append(s.allowlist, rule)
+	}
+	return nil
+}
+
+// Filter returns the results that are neither in the baseline nor allowed by the allowlist
+func (s *Suppression) Filter(owner, repo string, collectedResults map[string]CollectedResult) map[string]CollectedResult {
+	filteredResults := make(map[string]CollectedResult)
+	suppressed := 0
+	for matchedString, result := range collectedResults {
+		if s.IsSuppressed(matchedString, result) {
+			suppressed++
+			continue
+		}
+		filteredResults[matchedString] = result
+		s.found[result.Fingerprint] = BaselineEntry{
+			Fingerprint: result.Fingerprint,
+			Repo:        owner + "/" + repo,
+			Rule:        result.Rule,
+			Location:    result.Filename + ":" + result.Line,
+		}
+	}
+	if suppressed > 0 {
+		logger.Print(owner, repo, "suppress-results", "Suppressed", suppressed, "known results")
+	}
+	return filteredResults
+}
+
+// IsSuppressed reports whether a result is in the baseline or allowed by the allowlist, so that known
+// results are not logged as they are found
+func (s *Suppression) IsSuppressed(matchedString string, result CollectedResult) bool {
+	if s == nil {
+		return false
+	}
+	if _, exists := s.baseline[result.Fingerprint]; exists {
+		return true
+	}
+	return s.isAllowed(matchedString, result)
+}
+
+func (s *Suppression) isAllowed(matchedString string, result CollectedResult) bool {
+	// values are matched without the variable name they were assigned to
+	value := result.Value
+	if value == "" {
+		value = strings.TrimPrefix(matchedString, "["+result.Rule+"] ")
+	}
+	repo := getRepoOfFilename(result.Filename)
+	for _, rule := range s.allowlist {
+		if rule.valueRegex != nil && !rule.valueRegex.MatchString(value) {
+			continue
+		}
+		if rule.pathRegex != nil && !rule.pathRegex.MatchString(result.Filename) {
+			continue
+		}
+		if rule.Repo != "" {
+			if isMatch, _ := path.Match(rule.Repo, repo); !isMatch {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// WriteBaseline saves the findings of the baseline that was read along with every finding that was
+// let through, so the next scan only reports what is new
+func (s *Suppression) WriteBaseline(baselinePath string) error {
+	baseline := baselineFile{
+		Findings: []BaselineEntry{},
+	}
+	for _, entries := range []map[string]BaselineEntry{s.baseline, s.found} {
+		for _, entry := range entries {
+			baseline.Findings = append(baseline.Findings, entry)
+		}
+	}
+	sort.Slice(baseline.Findings, func(i, j int) bool {
+		return baseline.Findings[i].Fingerprint < baseline.Findings[j].Fingerprint
+	})
+	contents, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(baselinePath, contents, 0644)
+}
@@ -0,0 +1,152 @@
+package explore
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bm402/gander/internal/actionslog"
+)
+
+var awsSecretKeyRegex = regexp.MustCompile(`(?:^|[^A-Za-z0-9/+])([A-Za-z0-9/+]{40})(?:[^A-Za-z0-9/+=]|$)`)
+
+// awsVerifier calls sts get-caller-identity, which needs the secret key as well as the access key
+// id, so the secret key is looked for in the lines around the access key id
+type awsVerifier struct {
+	baseUrl    string
+	httpClient *http.Client
+}
+
+func (v *awsVerifier) Supports(rule string) bool {
+	return rule == "aws-access-key-id"
+}
+
+func (v *awsVerifier) Verify(secret string, result CollectedResult) (string, error) {
+	secretKeys := findAwsSecretKeys(result)
+	if len(secretKeys) == 0 {
+		return VERIFICATION_UNKNOWN, nil
+	}
+
+	for _, secretKey := range secretKeys {
+		status, err := v.getCallerIdentity(secret, secretKey)
+		if err != nil || status != VERIFICATION_UNKNOWN {
+			return status, err
+		}
+	}
+	return VERIFICATION_UNKNOWN, nil
+}
+
+func (v *awsVerifier) getCallerIdentity(accessKeyId, secretKey string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, v.baseUrl+"/", strings.NewReader(AWS_GET_CALLER_IDENTITY_BODY))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signAwsRequest(req, accessKeyId, secretKey, time.Now())
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return VERIFICATION_LIVE, nil
+	case strings.Contains(string(body), "InvalidClientTokenId"):
+		return VERIFICATION_REVOKED, nil
+	case strings.Contains(string(body), "SignatureDoesNotMatch"):
+		// the access key id exists but this is not its secret key
+		return VERIFICATION_UNKNOWN, nil
+	default:
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+// signAwsRequest adds a signature version 4 authorization header for sts
+func signAwsRequest(req *http.Request, accessKeyId, secretKey string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	signedHeaders := "content-type;host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		"",
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		hashSha256(AWS_GET_CALLER_IDENTITY_BODY),
+	}, "\n")
+
+	credentialScope := date + "/" + AWS_REGION + "/sts/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + credentialScope + "\n" + hashSha256(canonicalRequest)
+
+	signingKey := hmacSha256([]byte("AWS4"+secretKey), date)
+	signingKey = hmacSha256(signingKey, AWS_REGION)
+	signingKey = hmacSha256(signingKey, "sts")
+	signingKey = hmacSha256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyId+"/"+credentialScope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hashSha256(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSha256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+func findAwsSecretKeys(result CollectedResult) []string {
+	lineNumber, err := strconv.Atoi(result.Line)
+	if err != nil {
+		return []string{}
+	}
+	file, err := os.Open(result.Filename)
+	if err != nil {
+		return []string{}
+	}
+	defer file.Close()
+
+	secretKeys := []string{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), actionslog.MAX_LINE_LENGTH)
+	for currentLine := 1; scanner.Scan(); currentLine++ {
+		if currentLine < lineNumber-AWS_SECRET_KEY_SEARCH_LINES {
+			continue
+		}
+		if currentLine > lineNumber+AWS_SECRET_KEY_SEARCH_LINES {
+			break
+		}
+		for _, match := range awsSecretKeyRegex.FindAllStringSubmatch(scanner.Text(), -1) {
+			secretKeys = append(secretKeys, match[1])
+		}
+	}
+	return secretKeys
+}
@@ -0,0 +1,41 @@
+package explore
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type gitHubVerifier struct {
+	baseUrl    string
+	httpClient *http.Client
+}
+
+// refresh tokens can only be exchanged for a new token, so the api rejects them even when they are live
+func (v *gitHubVerifier) Supports(rule string) bool {
+	return strings.HasPrefix(rule, "github-") && rule != "github-refresh-token"
+}
+
+func (v *gitHubVerifier) Verify(secret string, result CollectedResult) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, v.baseUrl+"/user", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "token "+secret)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return VERIFICATION_LIVE, nil
+	case http.StatusUnauthorized:
+		return VERIFICATION_REVOKED, nil
+	default:
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,60 @@
+package explore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+var revokedSlackErrors = map[string]bool{
+	"invalid_auth":     true,
+	"token_revoked":    true,
+	"token_expired":    true,
+	"account_inactive": true,
+	"not_authed":       true,
+}
+
+type slackVerifier struct {
+	baseUrl    string
+	httpClient *http.Client
+}
+
+type slackAuthTestResponse struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func (v *slackVerifier) Supports(rule string) bool {
+	return rule == "slack-token"
+}
+
+func (v *slackVerifier) Verify(secret string, result CollectedResult) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, v.baseUrl+"/api/auth.test", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+secret)
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	authTest := slackAuthTestResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&authTest)
+	if err != nil {
+		return "", err
+	}
+
+	if authTest.Ok {
+		return VERIFICATION_LIVE, nil
+	}
+	if revokedSlackErrors[authTest.Error] {
+		return VERIFICATION_REVOKED, nil
+	}
+	return "", fmt.Errorf("unexpected error %s", authTest.Error)
+}
@@ -0,0 +1,63 @@
+package explore
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bm402/gander/internal/logger"
+)
+
+// Verifier checks whether a secret found by a signature is still accepted by its provider
+type Verifier interface {
+	Supports(rule string) bool
+	Verify(secret string, result CollectedResult) (string, error)
+}
+
+type VerifierOptions struct {
+	GitHubUrl string
+	SlackUrl  string
+	AwsUrl    string
+}
+
+func CreateVerifiers(opts VerifierOptions) []Verifier {
+	httpClient := &http.Client{
+		Timeout: VERIFICATION_TIMEOUT,
+	}
+	return []Verifier{
+		&gitHubVerifier{baseUrl: strings.TrimSuffix(opts.GitHubUrl, "/"), httpClient: httpClient},
+		&slackVerifier{baseUrl: strings.TrimSuffix(opts.SlackUrl, "/"), httpClient: httpClient},
+		&awsVerifier{baseUrl: strings.TrimSuffix(opts.AwsUrl, "/"), httpClient: httpClient},
+	}
+}
+
+// VerifyResults sets the verification status of every result that a verifier supports
+func VerifyResults(owner, repo string, collectedResults map[string]CollectedResult, verifiers []Verifier) {
+	for matchedString, result := range collectedResults {
+		verifier := findVerifier(verifiers, result.Rule)
+		if verifier == nil {
+			continue
+		}
+
+		secret := strings.TrimPrefix(matchedString, "["+result.Rule+"] ")
+		status, err := verifier.Verify(secret, result)
+		if err != nil {
+			logger.Print(owner, repo, "verify-secrets", "Could not verify", FormatMatchedString(matchedString, result)+":", err.Error())
+			status = VERIFICATION_UNKNOWN
+		}
+		result.Verification = status
+		collectedResults[matchedString] = result
+		logger.Print(owner, repo, "verify-secrets", FormatMatchedString(matchedString, result), "is", status)
+	}
+}
+
+func findVerifier(verifiers []Verifier, rule string) Verifier {
+	if rule == "" {
+		return nil
+	}
+	for _, verifier := range verifiers {
+		if verifier.Supports(rule) {
+			return verifier
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package explore
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bm402/gander/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+var untrustedExpressionRegex = regexp.MustCompile(`\$\{\{\s*(github\.event\.[A-Za-z0-9_.\-\[\]'"*]+|github\.head_ref)\s*\}\}`)
+var secretExpressionRegex = regexp.MustCompile(`\$\{\{\s*secrets\.[A-Za-z0-9_]+\s*\}\}`)
+var printCommandRegex = regexp.MustCompile(`(^|[;&|\s])(echo|printf|print|cat|Write-Host|Write-Output)(\s|$)`)
+var pullRequestHeadRegex = regexp.MustCompile(`github\.event\.pull_request\.head\.|github\.head_ref`)
+
+type workflowFinding struct {
+	rule          string
+	matchedString string
+	line          int
+}
+
+func ScanWorkflowFiles(owner, repo string, workflowFiles map[string][]byte, suppression *Suppression) map[string]CollectedResult {
+	collectedResults := make(map[string]CollectedResult)
+	filesByMatchedString := make(map[string]map[string]bool)
+
+	// workflow files are scanned in order so the first occurrence of a finding is the same every scan
+	workflowPaths := []string{}
+	for workflowPath := range workflowFiles {
+		workflowPaths = append(workflowPaths, workflowPath)
+	}
+	sort.Strings(workflowPaths)
+	for _, workflowPath := range workflowPaths {
+		filename := path.Join(owner, repo, workflowPath)
+		findings, err := scanWorkflowFile(workflowFiles[workflowPath])
+		if err != nil {
+			logger.Print(owner, repo, "scan-workflow-files", "Could not parse", workflowPath+":", err.Error())
+			continue
+		}
+
+		for _, finding := range findings {
+			matchedString := "[" + finding.rule + "] " + finding.matchedString
+			if existingCollectedResult, exists := collectedResults[matchedString]; exists {
+				updatedCollectedResult := existingCollectedResult
+				updatedCollectedResult.Occurrences++
+				if !filesByMatchedString[matchedString][filename] {
+					filesByMatchedString[matchedString][filename] = true
+					updatedCollectedResult.Files++
+				}
+				collectedResults[matchedString] = updatedCollectedResult
+				continue
+			}
+			filesByMatchedString[matchedString] = map[string]bool{filename: true}
+			collectedResults[matchedString] = CollectedResult{
+				Filename:    filename,
+				Line:        strconv.Itoa(finding.line),
+				Files:       1,
+				Occurrences: 1,
+				Confidence:  1,
+				Rule:        finding.rule,
+				Fingerprint: createFingerprint(finding.rule, finding.matchedString, filename, workflowPath),
+			}
+			if !suppression.IsSuppressed(matchedString, collectedResults[matchedString]) {
+				logger.Print(owner, repo, "\033[1;91mmatched-workflow-file\033[0m", "Found",
+					FormatResult(matchedString, collectedResults[matchedString]))
+			}
+		}
+	}
+	return collectedResults
+}
+
+func scanWorkflowFile(contents []byte) ([]workflowFinding, error) {
+	document := yaml.Node{}
+	err := yaml.Unmarshal(contents, &document)
+	if err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return []workflowFinding{}, nil
+	}
+	workflow := document.Content[0]
+
+	findings := []workflowFinding{}
+	findings = append(findings, checkPermissions(workflow)...)
+	isPullRequestTarget := hasTrigger(getMappingValue(workflow, "on"), "pull_request_target")
+
+	jobs := getMappingValue(workflow, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return findings, nil
+	}
+	for i := 1; i < len(jobs.Content); i += 2 {
+		job := jobs.Content[i]
+		findings = append(findings, checkPermissions(job)...)
+
+		steps := getMappingValue(job, "steps")
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, step := range steps.Content {
+			if run := getMappingValue(step, "run"); run != nil && run.Kind == yaml.ScalarNode {
+				findings = append(findings, checkRunScript(run)...)
+			}
+			if isPullRequestTarget {
+				findings = append(findings, checkPullRequestTargetCheckout(step)...)
+			}
+		}
+	}
+	return findings, nil
+}
+
+func checkPermissions(node *yaml.Node) []workflowFinding {
+	permissions := getMappingValue(node, "permissions")
+	if permissions != nil && permissions.Kind == yaml.ScalarNode && permissions.Value == "write-all" {
+		return []workflowFinding{{
+			rule:          "write-all-permissions",
+			matchedString: "permissions: write-all",
+			line:          permissions.Line,
+		}}
+	}
+	return []workflowFinding{}
+}
+
+// expressions are substituted into the script before it runs, so attacker controlled event fields
+// can inject shell commands, and secrets are printed even though the log masks them
+func checkRunScript(run *yaml.Node) []workflowFinding {
+	findings := []workflowFinding{}
+	for idx, scriptLine := range strings.Split(run.Value, "\n") {
+		line := run.Line
+		if run.Style == yaml.LiteralStyle || run.Style == yaml.FoldedStyle {
+			line += idx + 1
+		}
+		for _, expression := range untrustedExpressionRegex.FindAllString(scriptLine, -1) {
+			findings = append(findings, workflowFinding{
+				rule:          "script-injection",
+				matchedString: expression,
+				line:          line,
+			})
+		}
+		if printCommandRegex.MatchString(scriptLine) && secretExpressionRegex.MatchString(scriptLine) {
+			findings = append(findings, workflowFinding{
+				rule:          "secret-printed",
+				matchedString: strings.TrimSpace(scriptLine),
+				line:          line,
+			})
+		}
+	}
+	return findings
+}
+
+func checkPullRequestTargetCheckout(step *yaml.Node) []workflowFinding {
+	uses := getMappingValue(step, "uses")
+	if uses == nil || !strings.HasPrefix(uses.Value, "actions/checkout") {
+		return []workflowFinding{}
+	}
+	ref := getMappingValue(getMappingValue(step, "with"), "ref")
+	if ref == nil || !pullRequestHeadRegex.MatchString(ref.Value) {
+		return []workflowFinding{}
+	}
+	return []workflowFinding{{
+		rule:          "pull-request-target-checkout",
+		matchedString: "pull_request_target checkout of " + ref.Value,
+		line:          ref.Line,
+	}}
+}
+
+// triggers can be given as a single event, a list of events or a map of events to their filters
+func hasTrigger(on *yaml.Node, trigger string) bool {
+	if on == nil {
+		return false
+	}
+	switch on.Kind {
+	case yaml.ScalarNode:
+		return on.Value == trigger
+	case yaml.SequenceNode:
+		for _, event := range on.Content {
+			if event.Value == trigger {
+				return true
+			}
+		}
+	case yaml.MappingNode:
+		return getMappingValue(on, trigger) != nil
+	}
+	return false
+}
+
+func getMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package githubconfig
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// appTokenSource exchanges a jwt signed with the github app private key for an installation token,
+// which is wrapped in an oauth2.ReuseTokenSource so a new one is minted whenever the last one expires
+type appTokenSource struct {
+	client         *http.Client
+	baseUrl        string
+	appId          int64
+	installationId int64
+	privateKey     *rsa.PrivateKey
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func newAppTokenSource(client *http.Client, baseUrl string, appId, installationId int64, privateKeyPath string) (oauth2.TokenSource, error) {
+	privateKeyBytes, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := parsePrivateKey(privateKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return oauth2.ReuseTokenSource(nil, &appTokenSource{
+		client:         client,
+		baseUrl:        strings.TrimSuffix(baseUrl, "/") + "/",
+		appId:          appId,
+		installationId: installationId,
+		privateKey:     privateKey,
+	}), nil
+}
+
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.createJwt()
+	if err != nil {
+		return nil, err
+	}
+
+	url := s.baseUrl + "app/installations/" + strconv.FormatInt(s.installationId, 10) + "/access_tokens"
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("could not create installation token: %s %s", resp.Status, string(body))
+	}
+	token := installationToken{}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.Token,
+		TokenType:   "token",
+		Expiry:      token.ExpiresAt.Add(-APP_TOKEN_REFRESH_MARGIN),
+	}, nil
+}
+
+func (s *appTokenSource) createJwt() (string, error) {
+	// backdate the issue time to allow for clock drift between us and github
+	now := time.Now()
+	header := map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+	}
+	claims := map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(APP_JWT_LIFETIME).Unix(),
+		"iss": s.appId,
+	}
+
+	headerJson, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJson, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(headerJson) + "." + base64.RawURLEncoding.EncodeToString(claimsJson)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parsePrivateKey(privateKeyBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyBytes)
+	if block == nil {
+		return nil, errors.New("private key is not pem encoded")
+	}
+	if privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an rsa key")
+	}
+	return privateKey, nil
+}
@@ -1,20 +1,111 @@
 package githubconfig
 
 import (
-	"context"
-	"os"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
 
+	"github.com/bm402/gander/internal/logger"
 	"github.com/google/go-github/v37/github"
 	"golang.org/x/oauth2"
 )
 
-func CreateGitHubClient() *github.Client {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{
-			AccessToken: os.Getenv("GH_TOKEN"),
-		},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	return github.NewClient(tc)
+type ServerConfig struct {
+	BaseUrl      string
+	UploadUrl    string
+	CaBundlePath string
+}
+
+type AuthConfig struct {
+	TokenFilePath     string
+	AppId             int64
+	AppInstallationId int64
+	AppPrivateKeyPath string
+}
+
+func CreateGitHubClient(server ServerConfig, auth AuthConfig) *github.Client {
+	httpClient := &http.Client{}
+	gh := createClientForServer(server, httpClient)
+
+	// the transport is set once the client has worked out the api url, which app auth also needs
+	httpClient.Transport = newRateLimitTransport(createAuthTransport(createBaseTransport(server), gh.BaseURL.String(), auth))
+	return gh
+}
+
+// CreateDownloadClient returns a client for following log archive redirects, which trusts the same
+// certificates as the api client but never sends the api credentials
+func CreateDownloadClient(server ServerConfig) *http.Client {
+	return &http.Client{
+		Transport: createBaseTransport(server),
+	}
+}
+
+func createClientForServer(server ServerConfig, httpClient *http.Client) *github.Client {
+	if server.BaseUrl == "" {
+		return github.NewClient(httpClient)
+	}
+
+	uploadUrl := server.UploadUrl
+	if uploadUrl == "" {
+		uploadUrl = server.BaseUrl
+	}
+	gh, err := github.NewEnterpriseClient(server.BaseUrl, uploadUrl, httpClient)
+	if err != nil {
+		logger.Fatal("Could not create GitHub Enterprise client:", err.Error())
+	}
+	return gh
+}
+
+func createBaseTransport(server ServerConfig) http.RoundTripper {
+	if server.CaBundlePath == "" {
+		return http.DefaultTransport
+	}
+
+	caBundle, err := ioutil.ReadFile(server.CaBundlePath)
+	if err != nil {
+		logger.Fatal("Could not read CA bundle:", err.Error())
+	}
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM(caBundle) {
+		logger.Fatal("Could not find any certificates in CA bundle", server.CaBundlePath)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs: rootCAs,
+	}
+	return transport
+}
+
+func createAuthTransport(base http.RoundTripper, baseUrl string, auth AuthConfig) http.RoundTripper {
+	if auth.AppId == 0 && auth.AppInstallationId == 0 && auth.AppPrivateKeyPath == "" {
+		tokens, err := loadTokens(auth.TokenFilePath)
+		if err != nil {
+			logger.Fatal("Could not read tokens:", err.Error())
+		}
+		if len(tokens) == 0 {
+			logger.Print("gander", "", "auth", "No tokens found, making unauthenticated requests")
+			return base
+		}
+		if len(tokens) > 1 {
+			logger.Print("gander", "", "auth", "Rotating requests across", len(tokens), "tokens")
+		}
+		return newTokenPoolTransport(base, tokens)
+	}
+
+	if auth.AppId == 0 || auth.AppInstallationId == 0 || auth.AppPrivateKeyPath == "" {
+		logger.Fatal("GitHub App authentication needs an app id, an installation id and a private key file")
+	}
+	ts, err := newAppTokenSource(&http.Client{Transport: base}, baseUrl, auth.AppId, auth.AppInstallationId, auth.AppPrivateKeyPath)
+	if err != nil {
+		logger.Fatal("Could not read GitHub App private key:", err.Error())
+	}
+	return &oauth2.Transport{
+		Source: ts,
+		Base:   base,
+	}
 }
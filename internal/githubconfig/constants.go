@@ -0,0 +1,11 @@
+package githubconfig
+
+import "time"
+
+var RATE_LIMIT_RESET_MARGIN = time.Minute
+var SECONDARY_RATE_LIMIT_MARGIN = 5 * time.Second
+var SECONDARY_RATE_LIMIT_BACKOFF = 5 * time.Minute
+var MAX_SECONDARY_RATE_LIMIT_BACKOFF = 30 * time.Minute
+var MAX_RATE_LIMIT_RETRIES = 10
+var APP_JWT_LIFETIME = 9 * time.Minute
+var APP_TOKEN_REFRESH_MARGIN = 5 * time.Minute
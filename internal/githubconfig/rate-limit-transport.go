@@ -0,0 +1,146 @@
+package githubconfig
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bm402/gander/internal/logger"
+)
+
+// rateLimitTransport holds back every request while any of them has hit a primary or secondary rate
+// limit, so that all workers wait for the same reset instead of each retrying on their own
+type rateLimitTransport struct {
+	base     http.RoundTripper
+	mutex    sync.Mutex
+	resumeAt time.Time
+}
+
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{
+		base: base,
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	secondaryRateLimitHits := 0
+	for retries := 0; ; retries++ {
+		t.waitForResume()
+
+		attempt, err := rewindRequest(req, retries)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		canRetry := retries < MAX_RATE_LIMIT_RETRIES && (req.Body == nil || req.GetBody != nil)
+		if isPrimaryRateLimited(resp) {
+			t.pauseUntil(getRateLimitReset(resp).Add(RATE_LIMIT_RESET_MARGIN), "Rate limit hit")
+			if canRetry {
+				resp.Body.Close()
+				continue
+			}
+		} else if isSecondaryRateLimited(resp) {
+			secondaryRateLimitHits++
+			t.pauseUntil(getSecondaryRateLimitReset(resp, secondaryRateLimitHits), "Secondary rate limit hit")
+			if canRetry {
+				resp.Body.Close()
+				continue
+			}
+		} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			// the last request of the window succeeded, so hold off the next ones until the reset
+			t.pauseUntil(getRateLimitReset(resp).Add(RATE_LIMIT_RESET_MARGIN), "Rate limit reached")
+			t.waitForResume()
+		}
+		return resp, nil
+	}
+}
+
+func (t *rateLimitTransport) waitForResume() {
+	t.mutex.Lock()
+	resumeAt := t.resumeAt
+	t.mutex.Unlock()
+	time.Sleep(time.Until(resumeAt))
+}
+
+// only the request that extends the pause logs it, so each wait is reported once
+func (t *rateLimitTransport) pauseUntil(resumeAt time.Time, reason string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if resumeAt.After(t.resumeAt) {
+		t.resumeAt = resumeAt
+		logger.Print("gander", "", "rate-limit", reason+", waiting for reset at", resumeAt.String())
+	}
+}
+
+func rewindRequest(req *http.Request, retries int) (*http.Request, error) {
+	if retries == 0 || req.Body == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	attempt := req.Clone(req.Context())
+	attempt.Body = body
+	return attempt, nil
+}
+
+func isPrimaryRateLimited(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	return resp.Header.Get("X-RateLimit-Remaining") == "0" && getRateLimitReset(resp).After(time.Now())
+}
+
+func isSecondaryRateLimited(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	if resp.Header.Get("Retry-After") != "" {
+		return true
+	}
+
+	// the body has to be read to check for the message, so put it back for the caller
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(body), "secondary rate limit") || strings.Contains(string(body), "abuse detection")
+}
+
+func getRateLimitReset(resp *http.Response) time.Time {
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.Unix(reset, 0)
+}
+
+func getSecondaryRateLimitReset(resp *http.Response, hits int) time.Time {
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(retryAfter)*time.Second + SECONDARY_RATE_LIMIT_MARGIN)
+	}
+
+	// without a retry-after header, back off exponentially on repeated hits
+	backoff := SECONDARY_RATE_LIMIT_BACKOFF
+	for i := 1; i < hits && backoff < MAX_SECONDARY_RATE_LIMIT_BACKOFF; i++ {
+		backoff *= 2
+	}
+	if backoff > MAX_SECONDARY_RATE_LIMIT_BACKOFF {
+		backoff = MAX_SECONDARY_RATE_LIMIT_BACKOFF
+	}
+	return time.Now().Add(backoff)
+}
@@ -0,0 +1,180 @@
+package githubconfig
+
+import (
+	"bufio"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type pooledToken struct {
+	token     string
+	remaining int
+	reset     time.Time
+}
+
+// tokenPoolTransport sends each request with whichever token has the most quota left. The rate limit
+// headers of each response are replaced with the totals for the whole pool, so that the rate limit
+// transport and go-github only hold back requests once every token is exhausted
+type tokenPoolTransport struct {
+	base   http.RoundTripper
+	mutex  sync.Mutex
+	tokens []*pooledToken
+}
+
+func newTokenPoolTransport(base http.RoundTripper, tokens []string) *tokenPoolTransport {
+	pool := &tokenPoolTransport{
+		base: base,
+	}
+	for _, token := range tokens {
+		pool.tokens = append(pool.tokens, &pooledToken{
+			token:     token,
+			remaining: -1,
+		})
+	}
+	return pool
+}
+
+func (t *tokenPoolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		token := t.pickToken()
+		authReq, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		if attempt == 0 {
+			authReq = req.Clone(req.Context())
+		}
+		authReq.Header.Set("Authorization", "token "+token.token)
+
+		resp, err := t.base.RoundTrip(authReq)
+		if err != nil {
+			return nil, err
+		}
+		t.updateToken(token, resp)
+
+		// another token may still have quota if this one ran out since it was picked
+		canRetry := attempt < len(t.tokens) && (req.Body == nil || req.GetBody != nil)
+		if isPrimaryRateLimited(resp) && t.hasQuota() && canRetry {
+			resp.Body.Close()
+			continue
+		}
+		t.setPoolRateLimitHeaders(resp)
+		return resp, nil
+	}
+}
+
+func (t *tokenPoolTransport) pickToken() *pooledToken {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var best *pooledToken
+	for _, token := range t.tokens {
+		if !token.isAvailable() {
+			continue
+		}
+		// tokens that have not been used yet are tried first
+		if best == nil || (best.remaining >= 0 && (token.remaining < 0 || token.remaining > best.remaining)) {
+			best = token
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	// every token is exhausted, so use the one that resets first
+	for _, token := range t.tokens {
+		if best == nil || token.reset.Before(best.reset) {
+			best = token
+		}
+	}
+	return best
+}
+
+func (t *tokenPoolTransport) updateToken(token *pooledToken, resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	token.remaining = remaining
+	token.reset = getRateLimitReset(resp)
+}
+
+func (t *tokenPoolTransport) hasQuota() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, token := range t.tokens {
+		if token.isAvailable() {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tokenPoolTransport) setPoolRateLimitHeaders(resp *http.Response) {
+	if resp.Header.Get("X-RateLimit-Remaining") == "" {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	remaining := 0
+	var reset time.Time
+	for _, token := range t.tokens {
+		if token.remaining < 0 {
+			remaining++
+		} else if token.isAvailable() {
+			remaining += token.remaining
+		}
+		if reset.IsZero() || (!token.reset.IsZero() && token.reset.Before(reset)) {
+			reset = token.reset
+		}
+	}
+	resp.Header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	if !reset.IsZero() {
+		resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	}
+}
+
+func (t *pooledToken) isAvailable() bool {
+	return t.remaining != 0 || time.Now().After(t.reset)
+}
+
+// tokens are read from GH_TOKENS (comma separated) and the token file (one per line), falling back to
+// GH_TOKEN if neither gives any
+func loadTokens(tokenFilePath string) ([]string, error) {
+	tokens := []string{}
+	for _, token := range strings.Split(os.Getenv("GH_TOKENS"), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	if tokenFilePath != "" {
+		file, err := os.Open(tokenFilePath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if token := strings.TrimSpace(scanner.Text()); token != "" && !strings.HasPrefix(token, "#") {
+				tokens = append(tokens, token)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tokens) == 0 && os.Getenv("GH_TOKEN") != "" {
+		tokens = append(tokens, os.Getenv("GH_TOKEN"))
+	}
+	return tokens, nil
+}
@@ -0,0 +1,6 @@
+package metadata
+
+var JOBS_FILENAME = "jobs.json"
+var RUN_FILENAME = "run.json"
+var ID_FILENAME = "id"
+var ARTIFACTS_FOLDERNAME = "artifacts"
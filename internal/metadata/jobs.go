@@ -0,0 +1,67 @@
+package metadata
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+type Job struct {
+	Id         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Filename   string    `json:"filename"`
+	Conclusion string    `json:"conclusion,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	Steps      []Step    `json:"steps"`
+}
+
+type Step struct {
+	Number      int64     `json:"number"`
+	Name        string    `json:"name"`
+	Conclusion  string    `json:"conclusion,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+func WriteJobs(folderPath string, jobs []Job) error {
+	contents, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(folderPath, JOBS_FILENAME), contents, 0644)
+}
+
+func ReadJobs(folderPath string) ([]Job, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(folderPath, JOBS_FILENAME))
+	if err != nil {
+		return nil, err
+	}
+	jobs := []Job{}
+	err = json.Unmarshal(contents, &jobs)
+	return jobs, err
+}
+
+func GetJobByFilename(jobs []Job, filename string) (Job, bool) {
+	for _, job := range jobs {
+		if job.Filename == filename {
+			return job, true
+		}
+	}
+	return Job{}, false
+}
+
+// GetStepAtTime returns the step that was running at the given time, preferring the later step when
+// one finishes in the same second that the next one starts
+func (job Job) GetStepAtTime(at time.Time) (Step, bool) {
+	for i := len(job.Steps) - 1; i >= 0; i-- {
+		step := job.Steps[i]
+		if step.StartedAt.IsZero() || at.Before(step.StartedAt.Truncate(time.Second)) {
+			continue
+		}
+		if step.CompletedAt.IsZero() || !at.After(step.CompletedAt.Add(time.Second)) {
+			return step, true
+		}
+	}
+	return Step{}, false
+}
@@ -0,0 +1,40 @@
+package metadata
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+type Run struct {
+	Id           int64     `json:"id"`
+	Workflow     string    `json:"workflow"`
+	RunNumber    int       `json:"run_number"`
+	Branch       string    `json:"branch"`
+	CommitSha    string    `json:"commit_sha"`
+	CommitAuthor string    `json:"commit_author"`
+	Event        string    `json:"event"`
+	Status       string    `json:"status"`
+	Conclusion   string    `json:"conclusion"`
+	Url          string    `json:"url"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func WriteRun(folderPath string, run Run) error {
+	contents, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(folderPath, RUN_FILENAME), contents, 0644)
+}
+
+func ReadRun(folderPath string) (Run, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(folderPath, RUN_FILENAME))
+	if err != nil {
+		return Run{}, err
+	}
+	run := Run{}
+	err = json.Unmarshal(contents, &run)
+	return run, err
+}
@@ -2,3 +2,6 @@ package retrieval
 
 var PAGE_SIZE = 100
 var ERROR_RESPONSE_THRESHOLD = int64(200)
+var MAX_LOG_ARCHIVE_SIZE = int64(512 * 1024 * 1024)
+var WORKFLOWS_PATH = ".github/workflows"
+var UUID_FOLDERNAME_LENGTH = 36
@@ -0,0 +1,96 @@
+package retrieval
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bm402/gander/internal/logger"
+	"github.com/bm402/gander/internal/metadata"
+	"github.com/google/go-github/v37/github"
+)
+
+type ArtifactOptions struct {
+	IsEnabled         bool
+	MaxSize           int64
+	AllowedExtensions []string
+	DeniedExtensions  []string
+}
+
+// artifacts are unpacked into the run folder so they are searched along with the logs, and a
+// failure only loses the artifacts rather than the whole run
+func getArtifactsFromRun(gh *github.Client, downloadClient *http.Client, owner, repo string, runId int64, foldername string, opts ArtifactOptions) {
+	artifacts := getWorkflowRunArtifacts(gh, owner, repo, runId)
+	for _, artifact := range artifacts {
+		if artifact.GetExpired() {
+			continue
+		}
+		if artifact.GetSizeInBytes() > opts.MaxSize {
+			logger.Print(owner, repo, "download-artifacts", "Skipping artifact", artifact.GetName(), "of",
+				artifact.GetSizeInBytes(), "bytes, which is over the size limit")
+			continue
+		}
+
+		redirectUrl, _, err := gh.Actions.DownloadArtifact(context.TODO(), owner, repo, artifact.GetID(), true)
+		if err != nil {
+			logger.Print(owner, repo, "download-artifacts", "Could not get artifact", artifact.GetName(), "redirect url:", err.Error())
+			continue
+		}
+		archive, err := downloadWithSizeLimit(downloadClient, gh.BaseURL.ResolveReference(redirectUrl).String(), opts.MaxSize)
+		if err != nil {
+			logger.Print(owner, repo, "download-artifacts", "Could not download artifact", artifact.GetName()+":", err.Error())
+			continue
+		}
+
+		artifactFolder := strconv.FormatInt(artifact.GetID(), 10) + "_" + sanitiseFilename(artifact.GetName())
+		folderPath := filepath.Join(owner, repo, foldername, metadata.ARTIFACTS_FOLDERNAME, artifactFolder)
+		err = extractZipArchive(archive, folderPath, opts.MaxSize, func(name string) bool {
+			return isArtifactFileIncluded(name, opts)
+		})
+		if err != nil {
+			logger.Print(owner, repo, "download-artifacts", "Could not unzip artifact", artifact.GetName()+":", err.Error())
+			removeIncompleteFolder(owner, repo, folderPath)
+		}
+	}
+}
+
+func getWorkflowRunArtifacts(gh *github.Client, owner, repo string, runId int64) []*github.Artifact {
+	artifacts := []*github.Artifact{}
+	page := 1
+	for {
+		artifactList, _, err := gh.Actions.ListWorkflowRunArtifacts(context.TODO(), owner, repo, runId, &github.ListOptions{
+			Page:    page,
+			PerPage: PAGE_SIZE,
+		})
+		if err != nil {
+			logger.Print(owner, repo, "download-artifacts", "Could not retrieve page", page, "of artifacts for run",
+				strconv.FormatInt(runId, 10)+":", err.Error())
+			return artifacts
+		}
+		artifacts = append(artifacts, artifactList.Artifacts...)
+		if len(artifactList.Artifacts) < PAGE_SIZE {
+			return artifacts
+		}
+		page++
+	}
+}
+
+func isArtifactFileIncluded(name string, opts ArtifactOptions) bool {
+	extension := strings.ToLower(filepath.Ext(name))
+	for _, deniedExtension := range opts.DeniedExtensions {
+		if extension == strings.ToLower(deniedExtension) {
+			return false
+		}
+	}
+	if len(opts.AllowedExtensions) == 0 {
+		return true
+	}
+	for _, allowedExtension := range opts.AllowedExtensions {
+		if extension == strings.ToLower(allowedExtension) {
+			return true
+		}
+	}
+	return false
+}
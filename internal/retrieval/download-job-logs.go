@@ -0,0 +1,136 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bm402/gander/internal/logger"
+	"github.com/bm402/gander/internal/metadata"
+	"github.com/google/go-github/v37/github"
+)
+
+func getJobLogsFromRun(gh *github.Client, downloadClient *http.Client, owner, repo string, run *github.WorkflowRun, opts DownloadOptions) error {
+	runId := run.GetID()
+	workflowJobs, err := getWorkflowJobs(gh, owner, repo, runId)
+	if err != nil {
+		return err
+	}
+
+	foldername := createRandomFoldername(owner, repo)
+	folderPath := filepath.Join(owner, repo, foldername)
+	err = os.MkdirAll(folderPath, 0755)
+	if err != nil {
+		logger.Print(owner, repo, "download-job-logs", "Could not create", folderPath, "directory:", err.Error())
+		return err
+	}
+
+	// jobs that were skipped or cancelled before starting have no logs, so only fail if none download
+	jobs := []metadata.Job{}
+	for idx, workflowJob := range workflowJobs {
+		job := createJobMetadata(workflowJob, idx)
+		err = downloadJobLog(gh, downloadClient, owner, repo, workflowJob.GetID(), filepath.Join(folderPath, job.Filename))
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	if len(jobs) == 0 {
+		removeIncompleteFolder(owner, repo, folderPath)
+		return errors.New("no job logs could be downloaded for run " + strconv.FormatInt(runId, 10))
+	}
+
+	err = metadata.WriteJobs(folderPath, jobs)
+	if err != nil {
+		logger.Print(owner, repo, "download-job-logs", "Could not write job metadata:", err.Error())
+		removeIncompleteFolder(owner, repo, folderPath)
+		return err
+	}
+	if opts.Artifacts.IsEnabled {
+		getArtifactsFromRun(gh, downloadClient, owner, repo, runId, foldername, opts.Artifacts)
+	}
+	addRunMetadataToFolder(owner, repo, run, foldername)
+	addRunIdToFolder(owner, repo, runId, foldername)
+	return nil
+}
+
+func getWorkflowJobs(gh *github.Client, owner, repo string, runId int64) ([]*github.WorkflowJob, error) {
+	workflowJobs := []*github.WorkflowJob{}
+	page := 1
+	for {
+		jobs, _, err := gh.Actions.ListWorkflowJobs(context.TODO(), owner, repo, runId, &github.ListWorkflowJobsOptions{
+			ListOptions: github.ListOptions{
+				Page:    page,
+				PerPage: PAGE_SIZE,
+			},
+		})
+		if err != nil {
+			logger.Print(owner, repo, "download-job-logs", "Could not retrieve page", page, "of jobs for run", strconv.FormatInt(runId, 10)+":", err.Error())
+			return nil, err
+		}
+		workflowJobs = append(workflowJobs, jobs.Jobs...)
+		if len(jobs.Jobs) < PAGE_SIZE {
+			return workflowJobs, nil
+		}
+		page++
+	}
+}
+
+func downloadJobLog(gh *github.Client, downloadClient *http.Client, owner, repo string, jobId int64, path string) error {
+	redirectUrl, _, err := gh.Actions.GetWorkflowJobLogs(context.TODO(), owner, repo, jobId, true)
+	if err != nil {
+		return err
+	}
+	contents, err := downloadWithSizeLimit(downloadClient, gh.BaseURL.ResolveReference(redirectUrl).String(), MAX_LOG_ARCHIVE_SIZE)
+	if err != nil {
+		logger.Print(owner, repo, "download-job-logs", "Could not download the log for job", strconv.FormatInt(jobId, 10)+":", err.Error())
+		return err
+	}
+	err = ioutil.WriteFile(path, contents, 0644)
+	if err != nil {
+		logger.Print(owner, repo, "download-job-logs", "Could not write the log for job", strconv.FormatInt(jobId, 10)+":", err.Error())
+	}
+	return err
+}
+
+func createJobMetadata(workflowJob *github.WorkflowJob, idx int) metadata.Job {
+	job := metadata.Job{
+		Id:         workflowJob.GetID(),
+		Name:       workflowJob.GetName(),
+		Filename:   strconv.Itoa(idx) + "_" + sanitiseFilename(workflowJob.GetName()) + ".txt",
+		Conclusion: workflowJob.GetConclusion(),
+		StartedAt:  workflowJob.GetStartedAt().Time,
+		Steps:      []metadata.Step{},
+	}
+	for _, taskStep := range workflowJob.Steps {
+		job.Steps = append(job.Steps, metadata.Step{
+			Number:      taskStep.GetNumber(),
+			Name:        taskStep.GetName(),
+			Conclusion:  taskStep.GetConclusion(),
+			StartedAt:   taskStep.GetStartedAt().Time,
+			CompletedAt: taskStep.GetCompletedAt().Time,
+		})
+	}
+	return job
+}
+
+func sanitiseFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune("/\\:*?\"<>|", r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func removeIncompleteFolder(owner, repo, folderPath string) {
+	err := os.RemoveAll(folderPath)
+	if err != nil {
+		logger.Print(owner, repo, "download-job-logs", "Could not delete the incomplete download:", err.Error())
+	}
+}
@@ -1,47 +1,71 @@
 package retrieval
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
-	"os/exec"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
-	"time"
 
 	"github.com/bm402/gander/internal/logger"
+	"github.com/bm402/gander/internal/metadata"
 	"github.com/google/go-github/v37/github"
 	"github.com/google/uuid"
 )
 
-type runIdConfig struct {
-	id    int64
+type DownloadOptions struct {
+	IsJobLogs bool
+	Artifacts ArtifactOptions
+}
+
+type runConfig struct {
+	run   *github.WorkflowRun
 	count int
 }
 
-func DownloadLogsFromRunIds(gh *github.Client, owner, repo string, runIds []int64, threads int) int {
+func DownloadLogsFromRuns(gh *github.Client, downloadClient *http.Client, owner, repo string, runs []*github.WorkflowRun, opts DownloadOptions, threads int) int {
+	removeIncompleteDownloads(owner, repo)
+	downloadedRunIds := GetDownloadedRunIds(owner, repo)
+	newRuns := filterDownloadedRuns(runs, downloadedRunIds)
+	if skipped := len(runs) - len(newRuns); skipped > 0 {
+		logger.Print(owner, repo, "download-logs", "Skipping", skipped, "runs that have already been downloaded")
+	}
+	runs = newRuns
+
 	wg := sync.WaitGroup{}
-	runIdConfigs := make(chan runIdConfig, len(runIds))
-	fivePercent := len(runIds) / 20
+	runConfigs := make(chan runConfig, len(runs))
+	fivePercent := len(runs) / 20
 	successfulDownloads := int64(0)
 	errorResponseCounter := int64(0)
 
 	// create worker threads
 	for i := 0; i < threads; i++ {
-		go func(idConfigs <-chan runIdConfig, thread int) {
-			for idConfig := range idConfigs {
+		go func(runConfigs <-chan runConfig) {
+			for runConfig := range runConfigs {
 				// if multiple error responses, skip remaining runs because they are most likely also errors
 				if errorResponseCounter > ERROR_RESPONSE_THRESHOLD {
 					wg.Done()
 					continue
 				}
 				// status update in 5% increments if lots of downloads
-				if len(runIds) > 500 && idConfig.count > 0 && idConfig.count%fivePercent == 0 {
-					logger.Print(owner, repo, "download-logs", idConfig.count, "downloads attempted",
-						"("+strconv.Itoa((idConfig.count/fivePercent)*5)+"%)")
+				if len(runs) > 500 && runConfig.count > 0 && runConfig.count%fivePercent == 0 {
+					logger.Print(owner, repo, "download-logs", runConfig.count, "downloads attempted",
+						"("+strconv.Itoa((runConfig.count/fivePercent)*5)+"%)")
+				}
+				var err error
+				if opts.IsJobLogs {
+					err = getJobLogsFromRun(gh, downloadClient, owner, repo, runConfig.run, opts)
+				} else {
+					err = getLogsFromRun(gh, downloadClient, owner, repo, runConfig.run, opts)
 				}
-				err := getLogsFromRunId(gh, owner, repo, idConfig.id, thread)
 				if err == nil {
 					atomic.AddInt64(&successfulDownloads, 1)
 				} else {
@@ -49,20 +73,20 @@ func DownloadLogsFromRunIds(gh *github.Client, owner, repo string, runIds []int6
 				}
 				wg.Done()
 			}
-		}(runIdConfigs, i)
+		}(runConfigs)
 	}
 
-	// add urls to channel to trigger workers
-	for j := 0; j < len(runIds); j++ {
+	// add runs to channel to trigger workers
+	for j := 0; j < len(runs); j++ {
 		wg.Add(1)
-		runIdConfigs <- runIdConfig{
-			id:    runIds[j],
+		runConfigs <- runConfig{
+			run:   runs[j],
 			count: j,
 		}
 	}
 
 	// close channel and wait for threads to finish
-	close(runIdConfigs)
+	close(runConfigs)
 	wg.Wait()
 
 	if errorResponseCounter > ERROR_RESPONSE_THRESHOLD {
@@ -72,7 +96,50 @@ func DownloadLogsFromRunIds(gh *github.Client, owner, repo string, runIds []int6
 	return int(successfulDownloads)
 }
 
-func getLogsFromRunId(gh *github.Client, owner, repo string, runId int64, thread int) error {
+func getLogsFromRun(gh *github.Client, downloadClient *http.Client, owner, repo string, run *github.WorkflowRun, opts DownloadOptions) error {
+	foldername := createRandomFoldername(owner, repo)
+	url, err := getLogUrl(gh, owner, repo, run.GetID())
+	if err != nil {
+		return err
+	}
+	archive, err := downloadLogArchive(downloadClient, owner, repo, url)
+	if err != nil {
+		return err
+	}
+	err = unzipLogArchive(owner, repo, archive, foldername)
+	if err != nil {
+		return err
+	}
+	deleteDuplicateLogFiles(owner, repo, foldername)
+	if opts.Artifacts.IsEnabled {
+		getArtifactsFromRun(gh, downloadClient, owner, repo, run.GetID(), foldername, opts.Artifacts)
+	}
+	addRunMetadataToFolder(owner, repo, run, foldername)
+	addRunIdToFolder(owner, repo, run.GetID(), foldername)
+	return nil
+}
+
+// removeIncompleteDownloads deletes the run folders left by an interrupted download, so the run is
+// downloaded again, and only touches folders named like the ones createRandomFoldername makes
+func removeIncompleteDownloads(owner, repo string) {
+	entries, err := ioutil.ReadDir(filepath.Join(owner, repo))
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if _, err := uuid.Parse(entry.Name()); err != nil || len(entry.Name()) != UUID_FOLDERNAME_LENGTH || !entry.IsDir() {
+			continue
+		}
+		folderPath := filepath.Join(owner, repo, entry.Name())
+		if _, err := os.Stat(filepath.Join(folderPath, metadata.ID_FILENAME)); !os.IsNotExist(err) {
+			continue
+		}
+		logger.Print(owner, repo, "download-logs", "Removing incomplete download", entry.Name())
+		removeIncompleteFolder(owner, repo, folderPath)
+	}
+}
+
+func createRandomFoldername(owner, repo string) string {
 	foldername, err := uuid.NewRandom()
 	retries := 0
 	for err != nil {
@@ -83,117 +150,192 @@ func getLogsFromRunId(gh *github.Client, owner, repo string, runId int64, thread
 		retries++
 		foldername, err = uuid.NewRandom()
 	}
+	return foldername.String()
+}
 
-	url, err := getLogUrl(gh, owner, repo, runId, thread)
+func getLogUrl(gh *github.Client, owner, repo string, runId int64) (string, error) {
+	redirectUrl, _, err := gh.Actions.GetWorkflowRunLogs(context.TODO(), owner, repo, runId, true)
 	if err != nil {
-		return err
+		return "", err
 	}
-	err = downloadLogArchive(owner, repo, url, foldername.String())
+
+	// enterprise servers can redirect to a path on the same host
+	return gh.BaseURL.ResolveReference(redirectUrl).String(), nil
+}
+
+func downloadLogArchive(downloadClient *http.Client, owner, repo, url string) ([]byte, error) {
+	archive, err := downloadWithSizeLimit(downloadClient, url, MAX_LOG_ARCHIVE_SIZE)
 	if err != nil {
-		return err
+		logger.Print(owner, repo, "download-logs", "Could not download the log archive:", err.Error())
 	}
-	err = unzipLogArchive(owner, repo, foldername.String())
+	return archive, err
+}
+
+func downloadWithSizeLimit(downloadClient *http.Client, url string, maxSize int64) ([]byte, error) {
+	resp, err := downloadClient.Get(url)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	deleteDuplicateLogFiles(owner, repo, foldername.String())
-	addRunIdToFolder(owner, repo, runId, foldername.String())
-	return nil
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxSize {
+		return nil, fmt.Errorf("size %d exceeds limit of %d bytes", resp.ContentLength, maxSize)
+	}
+
+	// read one byte past the limit so oversized downloads without a content length are detected
+	contents, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(contents)) > maxSize {
+		return nil, fmt.Errorf("download exceeds limit of %d bytes", maxSize)
+	}
+	if resp.ContentLength >= 0 && int64(len(contents)) != resp.ContentLength {
+		return nil, fmt.Errorf("received %d of %d bytes", len(contents), resp.ContentLength)
+	}
+
+	return contents, nil
 }
 
-func getLogUrl(gh *github.Client, owner, repo string, runId int64, thread int) (string, error) {
-	redirectUrl, resp, err := gh.Actions.GetWorkflowRunLogs(context.TODO(), owner, repo, runId, true)
-	defer resp.Body.Close()
+func unzipLogArchive(owner, repo string, archive []byte, foldername string) error {
+	folderPath := filepath.Join(owner, repo, foldername)
+	err := extractZipArchive(archive, folderPath, MAX_LOG_ARCHIVE_SIZE, nil)
+	if err != nil {
+		logger.Print(owner, repo, "download-logs", "Could not unzip the log archive:", err.Error())
+		// remove partially extracted files so they are not searched
+		if rerr := os.RemoveAll(folderPath); rerr != nil {
+			logger.Print(owner, repo, "download-logs", "Could not delete the partially unzipped log archive:", rerr.Error())
+		}
+	}
+	return err
+}
 
-	for err != nil {
-		respBodyBytes, serr := ioutil.ReadAll(resp.Body)
-		if serr != nil {
-			respBodyBytes = []byte{}
+// only files accepted by isFileIncluded are extracted, or every file if it is nil
+func extractZipArchive(archive []byte, folderPath string, maxSize int64, isFileIncluded func(name string) bool) error {
+	zipReader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return err
+	}
+
+	// check declared sizes before writing anything to disk
+	files := []*zip.File{}
+	totalSize := uint64(0)
+	for _, file := range zipReader.File {
+		if isFileIncluded != nil && !file.FileInfo().IsDir() && !isFileIncluded(file.Name) {
+			continue
 		}
-		// on rate limit, wait and retry
-		if resp.StatusCode == 403 && resp.Rate.Remaining == 0 && resp.Rate.Reset.Time.After(time.Now()) {
-			rateReset := resp.Rate.Reset.Time.Add(time.Minute)
-			if thread == 0 {
-				logger.Print(owner, repo, "download-logs", "Rate limit hit, waiting for reset at", rateReset.String())
-			}
-			time.Sleep(time.Until(rateReset))
-			resp.Body.Close()
-			redirectUrl, resp, err = gh.Actions.GetWorkflowRunLogs(context.TODO(), owner, repo, runId, true)
-		} else if resp.StatusCode == 403 && strings.Contains(string(respBodyBytes), "secondary rate limit") {
-			var rateReset time.Time
-			if retryAfters, ok := resp.Header["Retry-After"]; ok {
-				retryAfter, _ := strconv.Atoi(retryAfters[0])
-				rateReset = time.Now().Add(time.Duration(retryAfter+5) * time.Second)
-			} else {
-				rateReset = time.Now().Add(5 * time.Minute)
-			}
-			if thread == 0 {
-				logger.Print(owner, repo, "download-logs", "Secondary rate limit hit, waiting for reset at", rateReset.String())
-			}
-			time.Sleep(time.Until(rateReset))
-			resp.Body.Close()
-			redirectUrl, resp, err = gh.Actions.GetWorkflowRunLogs(context.TODO(), owner, repo, runId, true)
-		} else if len(string(respBodyBytes)) > 0 {
-			logger.Print(owner, repo, "download-logs", "Could not get redirect url:", err.Error(), string(respBodyBytes))
-			return "", err
-		} else {
-			return "", err
+		files = append(files, file)
+		totalSize += file.UncompressedSize64
+		if totalSize > uint64(maxSize) {
+			return fmt.Errorf("uncompressed size exceeds limit of %d bytes", maxSize)
 		}
 	}
 
-	return redirectUrl.String(), nil
+	err = os.MkdirAll(folderPath, 0755)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		err = extractZipFile(file, folderPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func downloadLogArchive(owner, repo, url, foldername string) error {
-	err := exec.Command("mkdir", "-p", owner).Run()
-	if err != nil {
-		logger.Print(owner, repo, "download-logs", "Could not create", owner, "directory:", err.Error())
+func extractZipFile(file *zip.File, folderPath string) error {
+	// reject entries that would be written outside of the destination folder
+	path := filepath.Join(folderPath, file.Name)
+	if !strings.HasPrefix(path, filepath.Clean(folderPath)+string(os.PathSeparator)) {
+		return fmt.Errorf("illegal file path in archive: %s", file.Name)
 	}
-	err = exec.Command("mkdir", "-p", owner+"/"+repo).Run()
+
+	if file.FileInfo().IsDir() {
+		return os.MkdirAll(path, 0755)
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0755)
 	if err != nil {
-		logger.Print(owner, repo, "download-logs", "Could not create", owner+"/"+repo, "directory:", err.Error())
+		return err
 	}
-	err = exec.Command("wget", "-O", owner+"/"+repo+"/"+foldername+".zip", url).Run()
+
+	reader, err := file.Open()
 	if err != nil {
-		logger.Print(owner, repo, "download-logs", "Could not download the log archive:", err.Error())
+		return err
 	}
-	return err
-}
+	defer reader.Close()
 
-func unzipLogArchive(owner, repo, foldername string) error {
-	err := exec.Command("unzip", "-d", owner+"/"+repo+"/"+foldername, owner+"/"+repo+"/"+foldername+".zip").Run()
+	writer, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 	if err != nil {
-		logger.Print(owner, repo, "download-logs", "Could not unzip the log archive:", err.Error())
 		return err
 	}
+	defer writer.Close()
 
-	err = exec.Command("rm", owner+"/"+repo+"/"+foldername+".zip").Run()
+	// the zip reader verifies the crc32 checksum once the whole entry has been read
+	written, err := io.Copy(writer, io.LimitReader(reader, int64(file.UncompressedSize64)+1))
 	if err != nil {
-		logger.Print(owner, repo, "download-logs", "Could not delete the unzipped log archive:", err.Error())
+		return err
 	}
-	return err
+	if uint64(written) != file.UncompressedSize64 {
+		return fmt.Errorf("size mismatch for %s: expected %d bytes, got %d", file.Name, file.UncompressedSize64, written)
+	}
+	return writer.Close()
 }
 
+// run archives have the whole log of each job next to a folder with a log for each of its steps,
+// and the step logs are kept so that results can be traced back to the step that printed them
 func deleteDuplicateLogFiles(owner, repo, foldername string) {
-	folderOutput, err := exec.Command("find", owner+"/"+repo+"/"+foldername, "-mindepth", "1", "-maxdepth", "1", "-type", "d").Output()
+	folderPath := filepath.Join(owner, repo, foldername)
+	entries, err := ioutil.ReadDir(folderPath)
 	if err != nil {
-		logger.Print(owner, repo, "download-logs", "Could not find the duplicate directories:", err.Error())
+		logger.Print(owner, repo, "download-logs", "Could not find the duplicate log files:", err.Error())
 		return
 	}
 
-	folders := strings.Split(string(folderOutput), "\n")
-	for _, folder := range folders {
-		err := exec.Command("rm", "-rf", folder).Run()
+	jobFolders := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			jobFolders[entry.Name()] = true
+		}
+	}
+	for _, entry := range entries {
+		parts := strings.SplitN(strings.TrimSuffix(entry.Name(), ".txt"), "_", 2)
+		if entry.IsDir() || len(parts) != 2 || !jobFolders[parts[1]] {
+			continue
+		}
+		err := os.Remove(filepath.Join(folderPath, entry.Name()))
 		if err != nil {
-			logger.Print(owner, repo, "download-logs", "Could not delete the duplicate directories:", err.Error())
+			logger.Print(owner, repo, "download-logs", "Could not delete the duplicate log files:", err.Error())
 		}
 	}
 }
 
 func addRunIdToFolder(owner, repo string, runId int64, foldername string) {
 	contents := []byte(strconv.FormatInt(runId, 10) + "\n")
-	err := ioutil.WriteFile(owner+"/"+repo+"/"+foldername+"/id", contents, 0644)
+	err := ioutil.WriteFile(filepath.Join(owner, repo, foldername, metadata.ID_FILENAME), contents, 0644)
 	if err != nil {
 		logger.Print(owner, repo, "download-logs", "Could not write run id to folder:", err.Error())
 	}
 }
+
+func addRunMetadataToFolder(owner, repo string, run *github.WorkflowRun, foldername string) {
+	err := metadata.WriteRun(filepath.Join(owner, repo, foldername), metadata.Run{
+		Id:           run.GetID(),
+		Workflow:     run.GetName(),
+		RunNumber:    run.GetRunNumber(),
+		Branch:       run.GetHeadBranch(),
+		CommitSha:    run.GetHeadSHA(),
+		CommitAuthor: run.GetHeadCommit().GetAuthor().GetName(),
+		Event:        run.GetEvent(),
+		Status:       run.GetStatus(),
+		Conclusion:   run.GetConclusion(),
+		Url:          run.GetHTMLURL(),
+		CreatedAt:    run.GetCreatedAt().Time,
+	})
+	if err != nil {
+		logger.Print(owner, repo, "download-logs", "Could not write run metadata to folder:", err.Error())
+	}
+}
@@ -2,10 +2,7 @@ package retrieval
 
 import (
 	"context"
-	"io/ioutil"
 	"math"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -13,21 +10,35 @@ import (
 	"github.com/google/go-github/v37/github"
 )
 
-func GetAllRunIdsForRepo(gh *github.Client, owner, repo string, threads int) []int64 {
+type RunFilter struct {
+	Since  time.Time
+	Until  time.Time
+	Branch string
+	Event  string
+	Status string
+	Actor  string
+}
+
+func GetAllRunsForRepo(gh *github.Client, owner, repo string, filter RunFilter, threads int) []*github.WorkflowRun {
 	// get first page of workflow runs
-	workflowRunsFirstPage := getWorkflowRunsByPage(gh, owner, repo, 1, 0)
+	workflowRunsFirstPage := getWorkflowRunsByPage(gh, owner, repo, filter, 1)
 	if *workflowRunsFirstPage.TotalCount == 0 {
-		return []int64{}
+		return []*github.WorkflowRun{}
 	}
-	runIdsFirstPage := getRunIdsFromWorkflowRuns(workflowRunsFirstPage)
+	runsFirstPage := filterWorkflowRuns(workflowRunsFirstPage, filter)
 
 	// calculate totals
 	totalWorkflowRuns := *workflowRunsFirstPage.TotalCount
 	totalPages := int(math.Ceil(float64(totalWorkflowRuns) / float64(PAGE_SIZE)))
 
-	// create page ids array
-	runIdsByPage := make([][]int64, totalPages)
-	runIdsByPage[0] = runIdsFirstPage
+	// runs are listed newest first, so a scan since a date stops paging once it goes back past it
+	if !filter.Since.IsZero() {
+		return getRunsSince(gh, owner, repo, filter, workflowRunsFirstPage, runsFirstPage, totalPages)
+	}
+
+	// create page runs array
+	runsByPage := make([][]*github.WorkflowRun, totalPages)
+	runsByPage[0] = runsFirstPage
 
 	// get remaining pages of workflow runs
 	wg := sync.WaitGroup{}
@@ -35,12 +46,12 @@ func GetAllRunIdsForRepo(gh *github.Client, owner, repo string, threads int) []i
 
 	// create worker threads
 	for i := 0; i < threads; i++ {
-		go func(pages <-chan int, thread int) {
+		go func(pages <-chan int) {
 			for page := range pages {
-				runIdsByPage[page-1] = getRunIdsByPage(gh, owner, repo, page, thread)
+				runsByPage[page-1] = getRunsByPage(gh, owner, repo, filter, page)
 				wg.Done()
 			}
-		}(pages, i)
+		}(pages)
 	}
 
 	// add remaining pages to channel to trigger workers
@@ -53,83 +64,87 @@ func GetAllRunIdsForRepo(gh *github.Client, owner, repo string, threads int) []i
 	close(pages)
 	wg.Wait()
 
-	// combine run id page arrays
-	runIds := []int64{}
-	for _, runIdsForPage := range runIdsByPage {
-		runIds = append(runIds, runIdsForPage...)
+	// combine run page arrays
+	runs := []*github.WorkflowRun{}
+	for _, runsForPage := range runsByPage {
+		runs = append(runs, runsForPage...)
+	}
+
+	return runs
+}
+
+func getRunsSince(gh *github.Client, owner, repo string, filter RunFilter, workflowRuns *github.WorkflowRuns, runs []*github.WorkflowRun, totalPages int) []*github.WorkflowRun {
+	for page := 2; page <= totalPages && !isPageBefore(workflowRuns, filter.Since); page++ {
+		workflowRuns = getWorkflowRunsByPage(gh, owner, repo, filter, page)
+		runs = append(runs, filterWorkflowRuns(workflowRuns, filter)...)
 	}
+	return runs
+}
 
-	return runIds
+func isPageBefore(workflowRuns *github.WorkflowRuns, since time.Time) bool {
+	if len(workflowRuns.WorkflowRuns) == 0 {
+		return true
+	}
+	return workflowRuns.WorkflowRuns[len(workflowRuns.WorkflowRuns)-1].GetCreatedAt().Time.Before(since)
 }
 
-func getRunIdsByPage(gh *github.Client, owner, repo string, page, thread int) []int64 {
-	workflowRuns := getWorkflowRunsByPage(gh, owner, repo, page, thread)
-	return getRunIdsFromWorkflowRuns(workflowRuns)
+func getRunsByPage(gh *github.Client, owner, repo string, filter RunFilter, page int) []*github.WorkflowRun {
+	workflowRuns := getWorkflowRunsByPage(gh, owner, repo, filter, page)
+	return filterWorkflowRuns(workflowRuns, filter)
 }
 
-func getWorkflowRunsByPage(gh *github.Client, owner, repo string, page, thread int) *github.WorkflowRuns {
-	workflowRuns, resp, err := gh.Actions.ListRepositoryWorkflowRuns(context.TODO(), owner, repo, &github.ListWorkflowRunsOptions{
+func getWorkflowRunsByPage(gh *github.Client, owner, repo string, filter RunFilter, page int) *github.WorkflowRuns {
+	workflowRuns, _, err := gh.Actions.ListRepositoryWorkflowRuns(context.TODO(), owner, repo, &github.ListWorkflowRunsOptions{
+		Actor:  filter.Actor,
+		Branch: filter.Branch,
+		Event:  filter.Event,
+		Status: filter.Status,
 		ListOptions: github.ListOptions{
 			Page:    page,
 			PerPage: PAGE_SIZE,
 		},
 	})
-	defer resp.Body.Close()
-
-	for err != nil {
-		respBodyBytes, serr := ioutil.ReadAll(resp.Body)
-		if serr != nil {
-			respBodyBytes = []byte{}
-		}
-		// on rate limit, wait and retry
-		if _, ok := err.(*github.RateLimitError); ok {
-			rateReset := resp.Rate.Reset.Time.Add(time.Minute)
-			if thread == 0 {
-				logger.Print(owner, repo, "get-run-ids", "Rate limit hit, waiting for reset at", rateReset.String())
-			}
-			time.Sleep(time.Until(rateReset))
-			resp.Body.Close()
-			workflowRuns, resp, err = gh.Actions.ListRepositoryWorkflowRuns(context.TODO(), owner, repo, &github.ListWorkflowRunsOptions{
-				ListOptions: github.ListOptions{
-					Page:    page,
-					PerPage: PAGE_SIZE,
-				},
-			})
-		} else if resp.StatusCode == 403 && strings.Contains(string(respBodyBytes), "secondary rate limit") {
-			var rateReset time.Time
-			if retryAfters, ok := resp.Header["Retry-After"]; ok {
-				retryAfter, _ := strconv.Atoi(retryAfters[0])
-				rateReset = time.Now().Add(time.Duration(retryAfter+5) * time.Second)
-			} else {
-				rateReset = time.Now().Add(5 * time.Minute)
-			}
-			if thread == 0 {
-				logger.Print(owner, repo, "get-run-ids", "Secondary rate limit hit, waiting for reset at", rateReset.String())
-			}
-			time.Sleep(time.Until(rateReset))
-			resp.Body.Close()
-			workflowRuns, resp, err = gh.Actions.ListRepositoryWorkflowRuns(context.TODO(), owner, repo, &github.ListWorkflowRunsOptions{
-				ListOptions: github.ListOptions{
-					Page:    page,
-					PerPage: PAGE_SIZE,
-				},
-			})
-		} else {
-			logger.Print(owner, repo, "get-run-ids", "Could not retrieve page", page, "workflow runs:", err.Error())
-			totalCount := 0
-			workflowRuns, err = &github.WorkflowRuns{
-				TotalCount: &totalCount,
-			}, nil
+	if err != nil {
+		logger.Print(owner, repo, "get-run-ids", "Could not retrieve page", page, "workflow runs:", err.Error())
+		totalCount := 0
+		workflowRuns = &github.WorkflowRuns{
+			TotalCount: &totalCount,
 		}
 	}
 
 	return workflowRuns
 }
 
-func getRunIdsFromWorkflowRuns(workflowRuns *github.WorkflowRuns) []int64 {
-	runIds := []int64{}
+func filterWorkflowRuns(workflowRuns *github.WorkflowRuns, filter RunFilter) []*github.WorkflowRun {
+	runs := []*github.WorkflowRun{}
 	for _, workflowRun := range workflowRuns.WorkflowRuns {
-		runIds = append(runIds, *workflowRun.ID)
+		if isWorkflowRunFiltered(workflowRun, filter) {
+			continue
+		}
+		runs = append(runs, workflowRun)
+	}
+	return runs
+}
+
+// go-github has no field for the api's created parameter, so the date range is applied to the returned
+// runs here, along with the other filters as a safeguard (actor is not part of the run model, so it is
+// left to the api)
+func isWorkflowRunFiltered(workflowRun *github.WorkflowRun, filter RunFilter) bool {
+	createdAt := workflowRun.GetCreatedAt().Time
+	if !filter.Since.IsZero() && createdAt.Before(filter.Since) {
+		return true
+	}
+	if !filter.Until.IsZero() && createdAt.After(filter.Until) {
+		return true
+	}
+	if filter.Branch != "" && workflowRun.GetHeadBranch() != filter.Branch {
+		return true
+	}
+	if filter.Event != "" && workflowRun.GetEvent() != filter.Event {
+		return true
+	}
+	if filter.Status != "" && workflowRun.GetStatus() != filter.Status && workflowRun.GetConclusion() != filter.Status {
+		return true
 	}
-	return runIds
+	return false
 }
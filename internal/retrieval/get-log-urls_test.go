@@ -0,0 +1,60 @@
+package retrieval
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v37/github"
+)
+
+// three pages of runs, newest first, one day apart
+func createRunsServer(requestedPages map[int]bool) *httptest.Server {
+	newest := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	totalRuns := 3 * PAGE_SIZE
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		requestedPages[page] = true
+		runs := []*github.WorkflowRun{}
+		for i := (page - 1) * PAGE_SIZE; i < page*PAGE_SIZE; i++ {
+			id := int64(i)
+			createdAt := github.Timestamp{Time: newest.Add(-time.Duration(i) * 24 * time.Hour)}
+			runs = append(runs, &github.WorkflowRun{ID: &id, CreatedAt: &createdAt})
+		}
+		json.NewEncoder(w).Encode(github.WorkflowRuns{TotalCount: &totalRuns, WorkflowRuns: runs})
+	}))
+}
+
+func TestGetAllRunsForRepoStopsPagingAtSince(t *testing.T) {
+	requestedPages := make(map[int]bool)
+	server := createRunsServer(requestedPages)
+	defer server.Close()
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(server.URL + "/")
+
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Add(-time.Duration(PAGE_SIZE+5) * 24 * time.Hour)
+	runs := GetAllRunsForRepo(gh, "owner", "repo", RunFilter{Since: since}, 2)
+	if len(runs) != PAGE_SIZE+6 {
+		t.Errorf("expected %d runs since %s, got %d", PAGE_SIZE+6, since, len(runs))
+	}
+	if requestedPages[3] {
+		t.Errorf("expected the page of runs before since not to be requested")
+	}
+}
+
+func TestGetAllRunsForRepoGetsEveryPageWithoutSince(t *testing.T) {
+	requestedPages := make(map[int]bool)
+	server := createRunsServer(requestedPages)
+	defer server.Close()
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(server.URL + "/")
+
+	runs := GetAllRunsForRepo(gh, "owner", "repo", RunFilter{}, 2)
+	if len(runs) != 3*PAGE_SIZE {
+		t.Errorf("expected %d runs, got %d", 3*PAGE_SIZE, len(runs))
+	}
+}
@@ -2,10 +2,6 @@ package retrieval
 
 import (
 	"context"
-	"io/ioutil"
-	"strconv"
-	"strings"
-	"time"
 
 	"github.com/bm402/gander/internal/logger"
 	"github.com/google/go-github/v37/github"
@@ -30,52 +26,15 @@ func GetOrganisationMembers(gh *github.Client, organisation string) []string {
 }
 
 func getOrganisationMembersByPage(gh *github.Client, organisation string, page int) []string {
-	membersData, resp, err := gh.Organizations.ListMembers(context.TODO(), organisation, &github.ListMembersOptions{
+	membersData, _, err := gh.Organizations.ListMembers(context.TODO(), organisation, &github.ListMembersOptions{
 		ListOptions: github.ListOptions{
 			Page:    page,
 			PerPage: PAGE_SIZE,
 		},
 	})
-	defer resp.Body.Close()
-
-	for err != nil {
-		respBodyBytes, serr := ioutil.ReadAll(resp.Body)
-		if serr != nil {
-			respBodyBytes = []byte{}
-		}
-		// on rate limit, wait and retry
-		if resp.StatusCode == 403 && resp.Rate.Remaining == 0 && resp.Rate.Reset.Time.After(time.Now()) {
-			rateReset := resp.Rate.Reset.Time.Add(time.Minute)
-			logger.Print(organisation, "", "get-org-members", "Rate limit hit, waiting for reset at", rateReset.String())
-			time.Sleep(time.Until(rateReset))
-			resp.Body.Close()
-			membersData, resp, err = gh.Organizations.ListMembers(context.TODO(), organisation, &github.ListMembersOptions{
-				ListOptions: github.ListOptions{
-					Page:    page,
-					PerPage: PAGE_SIZE,
-				},
-			})
-		} else if resp.StatusCode == 403 && strings.Contains(string(respBodyBytes), "secondary rate limit") {
-			var rateReset time.Time
-			if retryAfters, ok := resp.Header["Retry-After"]; ok {
-				retryAfter, _ := strconv.Atoi(retryAfters[0])
-				rateReset = time.Now().Add(time.Duration(retryAfter+5) * time.Second)
-			} else {
-				rateReset = time.Now().Add(5 * time.Minute)
-			}
-			logger.Print(organisation, "", "get-org-members", "Secondary rate limit hit, waiting for reset at", rateReset.String())
-			time.Sleep(time.Until(rateReset))
-			resp.Body.Close()
-			membersData, resp, err = gh.Organizations.ListMembers(context.TODO(), organisation, &github.ListMembersOptions{
-				ListOptions: github.ListOptions{
-					Page:    page,
-					PerPage: PAGE_SIZE,
-				},
-			})
-		} else {
-			logger.Print(organisation, "", "get-org-members", "Could not retrieve page", page, "organisation members:", err.Error())
-			membersData, err = []*github.User{}, nil
-		}
+	if err != nil {
+		logger.Print(organisation, "", "get-org-members", "Could not retrieve page", page, "organisation members:", err.Error())
+		membersData = []*github.User{}
 	}
 
 	// extract members
@@ -2,11 +2,7 @@ package retrieval
 
 import (
 	"context"
-	"io/ioutil"
-	"strconv"
-	"strings"
 	"sync"
-	"time"
 
 	"github.com/bm402/gander/internal/logger"
 	"github.com/google/go-github/v37/github"
@@ -24,7 +20,7 @@ func GetUsersRepos(gh *github.Client, organisation string, users []string, threa
 
 	// create worker threads
 	for i := 0; i < threads; i++ {
-		go func(users <-chan userWithLocalId, thread int) {
+		go func(users <-chan userWithLocalId) {
 			for user := range users {
 				repos := []string{}
 				page := 1
@@ -41,7 +37,7 @@ func GetUsersRepos(gh *github.Client, organisation string, users []string, threa
 				reposByUser[user.localId] = repos
 				wg.Done()
 			}
-		}(usernames, i)
+		}(usernames)
 	}
 
 	// add users to channel to trigger workers
@@ -67,52 +63,15 @@ func GetUsersRepos(gh *github.Client, organisation string, users []string, threa
 }
 
 func getUserReposByPage(gh *github.Client, organisation, user string, page int) []string {
-	reposData, resp, err := gh.Repositories.List(context.TODO(), user, &github.RepositoryListOptions{
+	reposData, _, err := gh.Repositories.List(context.TODO(), user, &github.RepositoryListOptions{
 		ListOptions: github.ListOptions{
 			Page:    page,
 			PerPage: PAGE_SIZE,
 		},
 	})
-	defer resp.Body.Close()
-
-	for err != nil {
-		respBodyBytes, serr := ioutil.ReadAll(resp.Body)
-		if serr != nil {
-			respBodyBytes = []byte{}
-		}
-		// on rate limit, wait and retry
-		if resp.StatusCode == 403 && resp.Rate.Remaining == 0 && resp.Rate.Reset.Time.After(time.Now()) {
-			rateReset := resp.Rate.Reset.Time.Add(time.Minute)
-			logger.Print(organisation, user, "get-user-repos", "Rate limit hit, waiting for reset at", rateReset.String())
-			time.Sleep(time.Until(rateReset))
-			resp.Body.Close()
-			reposData, resp, err = gh.Repositories.ListByOrg(context.TODO(), organisation, &github.RepositoryListByOrgOptions{
-				ListOptions: github.ListOptions{
-					Page:    page,
-					PerPage: PAGE_SIZE,
-				},
-			})
-		} else if resp.StatusCode == 403 && strings.Contains(string(respBodyBytes), "secondary rate limit") {
-			var rateReset time.Time
-			if retryAfters, ok := resp.Header["Retry-After"]; ok {
-				retryAfter, _ := strconv.Atoi(retryAfters[0])
-				rateReset = time.Now().Add(time.Duration(retryAfter+5) * time.Second)
-			} else {
-				rateReset = time.Now().Add(5 * time.Minute)
-			}
-			logger.Print(organisation, user, "get-user-repos", "Secondary rate limit hit, waiting for reset at", rateReset.String())
-			time.Sleep(time.Until(rateReset))
-			resp.Body.Close()
-			reposData, resp, err = gh.Repositories.ListByOrg(context.TODO(), organisation, &github.RepositoryListByOrgOptions{
-				ListOptions: github.ListOptions{
-					Page:    page,
-					PerPage: PAGE_SIZE,
-				},
-			})
-		} else {
-			logger.Print(organisation, user, "get-user-repos", "Could not retrieve page", page, "user repos:", err.Error())
-			reposData, err = []*github.Repository{}, nil
-		}
+	if err != nil {
+		logger.Print(organisation, user, "get-user-repos", "Could not retrieve page", page, "user repos:", err.Error())
+		reposData = []*github.Repository{}
 	}
 
 	// extract repo names
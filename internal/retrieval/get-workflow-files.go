@@ -0,0 +1,45 @@
+package retrieval
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/bm402/gander/internal/logger"
+	"github.com/google/go-github/v37/github"
+)
+
+// GetWorkflowFiles returns the contents of each workflow definition in the repo keyed by its path
+func GetWorkflowFiles(gh *github.Client, owner, repo string) map[string][]byte {
+	workflowFiles := make(map[string][]byte)
+	_, entries, resp, err := gh.Repositories.GetContents(context.TODO(), owner, repo, WORKFLOWS_PATH, nil)
+	if err != nil {
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			logger.Print(owner, repo, "get-workflow-files", "Could not list workflow files:", err.Error())
+		}
+		return workflowFiles
+	}
+
+	for _, entry := range entries {
+		name := entry.GetName()
+		if entry.GetType() != "file" || (!strings.HasSuffix(name, ".yml") && !strings.HasSuffix(name, ".yaml")) {
+			continue
+		}
+		file, _, _, err := gh.Repositories.GetContents(context.TODO(), owner, repo, entry.GetPath(), nil)
+		if err != nil {
+			logger.Print(owner, repo, "get-workflow-files", "Could not get workflow file", entry.GetPath()+":", err.Error())
+			continue
+		}
+		if file == nil {
+			continue
+		}
+		contents, err := file.GetContent()
+		if err != nil {
+			logger.Print(owner, repo, "get-workflow-files", "Could not decode workflow file", entry.GetPath()+":", err.Error())
+			continue
+		}
+		workflowFiles[entry.GetPath()] = []byte(contents)
+	}
+
+	return workflowFiles
+}
@@ -0,0 +1,59 @@
+package retrieval
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bm402/gander/internal/logger"
+	"github.com/bm402/gander/internal/metadata"
+	"github.com/google/go-github/v37/github"
+)
+
+func GetDownloadedRunIds(owner, repo string) map[int64]bool {
+	downloadedRunIds := make(map[int64]bool)
+	repoPath := filepath.Join(owner, repo)
+	entries, err := ioutil.ReadDir(repoPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Print(owner, repo, "download-manifest", "Could not read downloaded runs:", err.Error())
+		}
+		return downloadedRunIds
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		contents, err := ioutil.ReadFile(filepath.Join(repoPath, entry.Name(), metadata.ID_FILENAME))
+
+		// the id file is written last, so a folder without one has not finished downloading
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			logger.Print(owner, repo, "download-manifest", "Could not read run id from", entry.Name()+":", err.Error())
+			continue
+		}
+
+		runId, err := strconv.ParseInt(strings.TrimSpace(string(contents)), 10, 64)
+		if err != nil {
+			logger.Print(owner, repo, "download-manifest", "Could not parse run id from", entry.Name()+":", err.Error())
+			continue
+		}
+		downloadedRunIds[runId] = true
+	}
+
+	return downloadedRunIds
+}
+
+func filterDownloadedRuns(runs []*github.WorkflowRun, downloadedRunIds map[int64]bool) []*github.WorkflowRun {
+	newRuns := []*github.WorkflowRun{}
+	for _, run := range runs {
+		if !downloadedRunIds[run.GetID()] {
+			newRuns = append(newRuns, run)
+		}
+	}
+	return newRuns
+}
@@ -0,0 +1,3 @@
+package workflow
+
+var DATE_FORMAT = "2006-01-02"
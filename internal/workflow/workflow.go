@@ -2,8 +2,10 @@ package workflow
 
 import (
 	"fmt"
-	"os/exec"
+	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/bm402/gander/internal/explore"
 	"github.com/bm402/gander/internal/githubconfig"
@@ -13,17 +15,62 @@ import (
 )
 
 type Opts struct {
-	Organisation      *string
-	Owner             *string
-	Repo              *string
-	WordlistVariables *string
-	WordlistKeywords  *string
-	ThreadsDownload   *int
-	ThreadsSearch     *int
-	IsDownload        *bool
-	IsSearch          *bool
-	IsOrgRepos        *bool
-	IsOrgMembersRepos *bool
+	Organisation          *string
+	Owner                 *string
+	Repo                  *string
+	WordlistVariables     *string
+	WordlistKeywords      *string
+	RulesPaths            *string
+	ThreadsDownload       *int
+	ThreadsSearch         *int
+	MinConfidence         *float64
+	IsSignatures          *bool
+	IsDecode              *bool
+	IsMaskLeaks           *bool
+	IsEnvDumps            *bool
+	Context               *int
+	ContextBefore         *int
+	ContextAfter          *int
+	IsRedact              *bool
+	PlaceholdersPath      *string
+	IsShowFiltered        *bool
+	IsVerify              *bool
+	VerifyGitHubUrl       *string
+	VerifySlackUrl        *string
+	VerifyAwsUrl          *string
+	IsDependencyConfusion *bool
+	RegistryNpmUrl        *string
+	RegistryPypiUrl       *string
+	RegistryRubygemsUrl   *string
+	RegistryPackagistUrl  *string
+	RegistryGoUrl         *string
+	RegistryRdapUrl       *string
+	IsDownload            *bool
+	IsSearch              *bool
+	IsOrgRepos            *bool
+	IsOrgMembersRepos     *bool
+	IsJobLogs             *bool
+	IsArtifacts           *bool
+	IsWorkflowFiles       *bool
+	ArtifactMaxSize       *int64
+	ArtifactAllow         *string
+	ArtifactDeny          *string
+	Since                 *string
+	Until                 *string
+	Branch                *string
+	Event                 *string
+	Status                *string
+	Actor                 *string
+	BaseUrl               *string
+	UploadUrl             *string
+	CaBundlePath          *string
+	TokenFilePath         *string
+	AppId                 *int64
+	AppInstallationId     *int64
+	AppPrivateKeyPath     *string
+	BaselinePath          *string
+	IsWriteBaseline       *bool
+	AllowlistPath         *string
 }
 
 func Run(opts Opts) {
@@ -32,33 +79,153 @@ func Run(opts Opts) {
 		*opts.IsSearch = true
 	}
 
+	runFilter := createRunFilter(opts)
+	suppression, err := explore.LoadSuppression(*opts.BaselinePath, *opts.AllowlistPath)
+	if err != nil {
+		logger.Fatal("Could not load the baseline or allowlist:", err.Error())
+	}
+
 	logger.Print("gander", "", "run", "Creating GitHub client")
-	gh := githubconfig.CreateGitHubClient()
+	serverConfig := githubconfig.ServerConfig{
+		BaseUrl:      *opts.BaseUrl,
+		UploadUrl:    *opts.UploadUrl,
+		CaBundlePath: *opts.CaBundlePath,
+	}
+	gh := githubconfig.CreateGitHubClient(serverConfig, githubconfig.AuthConfig{
+		TokenFilePath:     *opts.TokenFilePath,
+		AppId:             *opts.AppId,
+		AppInstallationId: *opts.AppInstallationId,
+		AppPrivateKeyPath: *opts.AppPrivateKeyPath,
+	})
+	downloadClient := githubconfig.CreateDownloadClient(serverConfig)
 
 	if *opts.Organisation != "" {
 		if !*opts.IsOrgRepos && !*opts.IsOrgMembersRepos {
 			*opts.IsOrgRepos = true
 			*opts.IsOrgMembersRepos = true
 		}
-		scanOrganisation(gh, opts)
+		scanOrganisation(gh, downloadClient, opts, runFilter, suppression)
 	} else if *opts.Owner != "" && *opts.Repo != "" {
-		scanRepoLogs(gh, opts)
+		collectedResults := scanRepoLogs(gh, downloadClient, opts, runFilter, suppression)
+		for _, matchedString := range explore.SortResults(collectedResults) {
+			logger.Print(*opts.Owner, *opts.Repo, "summary", explore.FormatResult(matchedString, collectedResults[matchedString]))
+		}
 	} else {
 		logger.Fatal("Incorrect combination of flags used. Either give an -org for a full organisation scan,",
 			"or both -owner and -repo for a single repository scan")
 	}
+
+	if *opts.IsWriteBaseline {
+		writeBaseline(opts, suppression)
+	}
+}
+
+func writeBaseline(opts Opts, suppression *explore.Suppression) {
+	if *opts.BaselinePath == "" {
+		logger.Fatal("A -baseline file is needed to write the baseline to")
+	}
+	err := suppression.WriteBaseline(*opts.BaselinePath)
+	if err != nil {
+		logger.Fatal("Could not write the baseline:", err.Error())
+	}
+	logger.Print("gander", "", "write-baseline", "Wrote baseline to", *opts.BaselinePath)
+}
+
+func createRunFilter(opts Opts) retrieval.RunFilter {
+	runFilter := retrieval.RunFilter{
+		Branch: *opts.Branch,
+		Event:  *opts.Event,
+		Status: *opts.Status,
+		Actor:  *opts.Actor,
+	}
+	if *opts.Since != "" {
+		since, err := parseDateFlag(*opts.Since)
+		if err != nil {
+			logger.Fatal("Could not parse -since date:", err.Error())
+		}
+		runFilter.Since = since
+	}
+	if *opts.Until != "" {
+		until, err := parseDateFlag(*opts.Until)
+		if err != nil {
+			logger.Fatal("Could not parse -until date:", err.Error())
+		}
+		// a plain date includes the whole day
+		if len(*opts.Until) == len(DATE_FORMAT) {
+			until = until.Add(24*time.Hour - time.Nanosecond)
+		}
+		runFilter.Until = until
+	}
+	return runFilter
+}
+
+func createDownloadOptions(opts Opts) retrieval.DownloadOptions {
+	return retrieval.DownloadOptions{
+		IsJobLogs: *opts.IsJobLogs,
+		Artifacts: retrieval.ArtifactOptions{
+			IsEnabled:         *opts.IsArtifacts,
+			MaxSize:           *opts.ArtifactMaxSize * 1024 * 1024,
+			AllowedExtensions: splitListFlag(*opts.ArtifactAllow),
+			DeniedExtensions:  splitListFlag(*opts.ArtifactDeny),
+		},
+	}
+}
+
+func createSearchOptions(opts Opts, suppression *explore.Suppression) explore.SearchOptions {
+	return explore.SearchOptions{
+		VariablesWordlistPath: *opts.WordlistVariables,
+		KeywordsWordlistPath:  *opts.WordlistKeywords,
+		RulesPaths:            splitListFlag(*opts.RulesPaths),
+		MinConfidence:         *opts.MinConfidence,
+		IsSignatures:          *opts.IsSignatures,
+		IsDecode:              *opts.IsDecode,
+		IsMaskLeaks:           *opts.IsMaskLeaks,
+		IsEnvDumps:            *opts.IsEnvDumps,
+		IsDependencyConfusion: *opts.IsDependencyConfusion,
+		ContextBefore:         getContextFlag(*opts.ContextBefore, *opts.Context),
+		ContextAfter:          getContextFlag(*opts.ContextAfter, *opts.Context),
+		IsRedact:              *opts.IsRedact,
+		PlaceholdersPath:      *opts.PlaceholdersPath,
+		IsShowFiltered:        *opts.IsShowFiltered,
+		Suppression:           suppression,
+	}
+}
+
+// the before and after flags override the number of context lines given for both
+func getContextFlag(value, context int) int {
+	if value >= 0 {
+		return value
+	}
+	return context
+}
+
+func splitListFlag(value string) []string {
+	values := []string{}
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func parseDateFlag(value string) (time.Time, error) {
+	if len(value) == len(DATE_FORMAT) {
+		return time.Parse(DATE_FORMAT, value)
+	}
+	return time.Parse(time.RFC3339, value)
 }
 
-func scanOrganisation(gh *github.Client, opts Opts) {
+func scanOrganisation(gh *github.Client, downloadClient *http.Client, opts Opts, runFilter retrieval.RunFilter, suppression *explore.Suppression) {
 	if *opts.IsOrgRepos {
-		scanOrganisationRepoLogs(gh, opts)
+		scanOrganisationRepoLogs(gh, downloadClient, opts, runFilter, suppression)
 	}
 	if *opts.IsOrgMembersRepos {
-		scanOrganisationMembersRepoLogs(gh, opts)
+		scanOrganisationMembersRepoLogs(gh, downloadClient, opts, runFilter, suppression)
 	}
 }
 
-func scanOrganisationRepoLogs(gh *github.Client, opts Opts) {
+func scanOrganisationRepoLogs(gh *github.Client, downloadClient *http.Client, opts Opts, runFilter retrieval.RunFilter, suppression *explore.Suppression) {
 	logger.Print(*opts.Organisation, "", "scan-org-repo-logs", "Getting organisation repos")
 	repos := retrieval.GetOrganisationRepos(gh, *opts.Organisation)
 	logger.Print(*opts.Organisation, "", "scan-org-repo-logs", "Found", len(repos), "organisation repos")
@@ -69,25 +236,17 @@ func scanOrganisationRepoLogs(gh *github.Client, opts Opts) {
 		*opts.Repo = repo
 		logger.Print(*opts.Owner, *opts.Repo, "scan-org-repo-logs", "Scanning", *opts.Owner+"/"+*opts.Repo,
 			fmt.Sprint("(", idx+1, "/", len(repos)), "repos in org)")
-		collectedResults := scanRepoLogs(gh, opts)
+		collectedResults := scanRepoLogs(gh, downloadClient, opts, runFilter, suppression)
 		appendGlobalCollectedResults(globalCollectedResults, collectedResults)
 	}
 
 	logger.Print(*opts.Organisation, "", "scan-org-repo-logs", "Finished scanning org repo logs")
-	for matchedString, collectedResult := range globalCollectedResults {
-		if collectedResult.IsCondensed {
-			logger.Print(*opts.Organisation, "", "summary", matchedString, "at",
-				collectedResult.Filename+":"+collectedResult.Line+", with", collectedResult.Occurrences,
-				"similar occurrences (probably randomly generated)")
-		} else {
-			logger.Print(*opts.Organisation, "", "summary", matchedString, "at",
-				collectedResult.Filename+":"+collectedResult.Line+", with", collectedResult.Occurrences,
-				"occurrences in", collectedResult.Files, "files")
-		}
+	for _, matchedString := range explore.SortResults(globalCollectedResults) {
+		logger.Print(*opts.Organisation, "", "summary", explore.FormatResult(matchedString, globalCollectedResults[matchedString]))
 	}
 }
 
-func scanOrganisationMembersRepoLogs(gh *github.Client, opts Opts) {
+func scanOrganisationMembersRepoLogs(gh *github.Client, downloadClient *http.Client, opts Opts, runFilter retrieval.RunFilter, suppression *explore.Suppression) {
 	logger.Print(*opts.Organisation, "", "scan-org-members-repo-logs", "Getting organisation members")
 	members := retrieval.GetOrganisationMembers(gh, *opts.Organisation)
 	logger.Print(*opts.Organisation, "", "scan-org-members-repo-logs", "Found", len(members), "members")
@@ -103,77 +262,94 @@ func scanOrganisationMembersRepoLogs(gh *github.Client, opts Opts) {
 		*opts.Repo = parts[1]
 		logger.Print(*opts.Owner, *opts.Repo, "scan-org-members-repo-logs", "Scanning", *opts.Owner+"/"+*opts.Repo,
 			fmt.Sprint("(", idx+1, "/", len(repos)), "members repos in org)")
-		collectedResults := scanRepoLogs(gh, opts)
+		collectedResults := scanRepoLogs(gh, downloadClient, opts, runFilter, suppression)
 		appendGlobalCollectedResults(globalCollectedResults, collectedResults)
 	}
 
 	logger.Print(*opts.Organisation, "", "scan-org-members-repo-logs", "Finished scanning org members repo logs")
-	for matchedString, collectedResult := range globalCollectedResults {
-		if collectedResult.IsCondensed {
-			logger.Print(*opts.Organisation, "", "summary", matchedString, "at",
-				collectedResult.Filename+":"+collectedResult.Line+", with", collectedResult.Occurrences,
-				"similar occurrences (probably randomly generated)")
-		} else {
-			logger.Print(*opts.Organisation, "", "summary", matchedString, "at",
-				collectedResult.Filename+":"+collectedResult.Line+", with", collectedResult.Occurrences,
-				"occurrences in", collectedResult.Files, "files")
-		}
+	for _, matchedString := range explore.SortResults(globalCollectedResults) {
+		logger.Print(*opts.Organisation, "", "summary", explore.FormatResult(matchedString, globalCollectedResults[matchedString]))
 	}
 }
 
-func scanRepoLogs(gh *github.Client, opts Opts) map[string]explore.CollectedResult {
+func scanRepoLogs(gh *github.Client, downloadClient *http.Client, opts Opts, runFilter retrieval.RunFilter, suppression *explore.Suppression) map[string]explore.CollectedResult {
 	collectedResults := make(map[string]explore.CollectedResult)
 	if *opts.IsDownload {
-		downloadRepoLogs(gh, opts)
+		downloadRepoLogs(gh, downloadClient, opts, runFilter)
 	}
 	if *opts.IsSearch {
-		collectedResults = searchRepoLogs(opts)
+		collectedResults = searchRepoLogs(opts, suppression)
+	}
+	if *opts.IsWorkflowFiles {
+		scanRepoWorkflowFiles(gh, opts, collectedResults, suppression)
+	}
+
+	// known results are left out before verifying, so their secrets are not sent anywhere again
+	collectedResults = suppression.Filter(*opts.Owner, *opts.Repo, collectedResults)
+	if *opts.IsVerify {
+		explore.VerifyResults(*opts.Owner, *opts.Repo, collectedResults, explore.CreateVerifiers(explore.VerifierOptions{
+			GitHubUrl: *opts.VerifyGitHubUrl,
+			SlackUrl:  *opts.VerifySlackUrl,
+			AwsUrl:    *opts.VerifyAwsUrl,
+		}))
 	}
 	return collectedResults
 }
 
-func downloadRepoLogs(gh *github.Client, opts Opts) {
-	logger.Print(*opts.Owner, *opts.Repo, "download-logs", "Getting run ids")
-	runIds := retrieval.GetAllRunIdsForRepo(gh, *opts.Owner, *opts.Repo, *opts.ThreadsDownload)
-	logger.Print(*opts.Owner, *opts.Repo, "download-logs", "Found", len(runIds), "run ids")
-	if len(runIds) < 1 {
+func downloadRepoLogs(gh *github.Client, downloadClient *http.Client, opts Opts, runFilter retrieval.RunFilter) {
+	logger.Print(*opts.Owner, *opts.Repo, "download-logs", "Getting runs")
+	runs := retrieval.GetAllRunsForRepo(gh, *opts.Owner, *opts.Repo, runFilter, *opts.ThreadsDownload)
+	logger.Print(*opts.Owner, *opts.Repo, "download-logs", "Found", len(runs), "runs")
+	if len(runs) < 1 {
 		logger.Print(*opts.Owner, *opts.Repo, "download-logs", "No logs found, skipping download")
 		return
 	}
 
 	logger.Print(*opts.Owner, *opts.Repo, "download-logs", "Downloading log files")
-	downloads := retrieval.DownloadLogsFromRunIds(gh, *opts.Owner, *opts.Repo, runIds, *opts.ThreadsDownload)
+	downloads := retrieval.DownloadLogsFromRuns(gh, downloadClient, *opts.Owner, *opts.Repo, runs, createDownloadOptions(opts), *opts.ThreadsDownload)
 	logger.Print(*opts.Owner, *opts.Repo, "download-logs", "Found", downloads, "log files")
 }
 
-func searchRepoLogs(opts Opts) map[string]explore.CollectedResult {
+func scanRepoWorkflowFiles(gh *github.Client, opts Opts, collectedResults map[string]explore.CollectedResult, suppression *explore.Suppression) {
+	logger.Print(*opts.Owner, *opts.Repo, "scan-workflow-files", "Getting workflow files")
+	workflowFiles := retrieval.GetWorkflowFiles(gh, *opts.Owner, *opts.Repo)
+	logger.Print(*opts.Owner, *opts.Repo, "scan-workflow-files", "Found", len(workflowFiles), "workflow files")
+	if len(workflowFiles) < 1 {
+		return
+	}
+
+	workflowFileResults := explore.ScanWorkflowFiles(*opts.Owner, *opts.Repo, workflowFiles, suppression)
+	logger.Print(*opts.Owner, *opts.Repo, "scan-workflow-files", "Finished scan,", len(workflowFileResults), "issues found")
+	for matchedString, collectedResult := range workflowFileResults {
+		collectedResults[matchedString] = collectedResult
+	}
+}
+
+func searchRepoLogs(opts Opts, suppression *explore.Suppression) map[string]explore.CollectedResult {
 	globalCollectedResults := make(map[string]explore.CollectedResult)
-	err := exec.Command("ls", *opts.Owner+"/"+*opts.Repo).Run()
+	_, err := os.Stat(*opts.Owner + "/" + *opts.Repo)
 	if err != nil {
 		logger.Print(*opts.Owner, *opts.Repo, "search-logs", "No logs found, skipping search")
 		return globalCollectedResults
 	}
 
-	if len(*opts.WordlistVariables) > 0 {
-		logger.Print(*opts.Owner, *opts.Repo, "search-logs", "Searching logs for variable assignments")
-		collectedResults := explore.SearchLogsForVariableAssignments(*opts.Owner, *opts.Repo, *opts.WordlistVariables, *opts.ThreadsSearch)
-		logger.Print(*opts.Owner, *opts.Repo, "search-logs", "Finished search,", len(collectedResults), "variable assignments found")
-		for matchedString, collectedResult := range collectedResults {
-			globalCollectedResults[matchedString] = collectedResult
-		}
-	} else {
-		logger.Print(*opts.Owner, *opts.Repo, "search-logs", "No variable names wordlist provided")
+	logger.Print(*opts.Owner, *opts.Repo, "search-logs", "Searching logs")
+	collectedResults := explore.SearchLogs(*opts.Owner, *opts.Repo, createSearchOptions(opts, suppression), *opts.ThreadsSearch)
+	logger.Print(*opts.Owner, *opts.Repo, "search-logs", "Finished search,", len(collectedResults), "results found")
+	if *opts.IsDependencyConfusion {
+		explore.VerifyResults(*opts.Owner, *opts.Repo, collectedResults, explore.CreateRegistryVerifiers(explore.RegistryOptions{
+			NpmUrl:       *opts.RegistryNpmUrl,
+			PypiUrl:      *opts.RegistryPypiUrl,
+			RubygemsUrl:  *opts.RegistryRubygemsUrl,
+			PackagistUrl: *opts.RegistryPackagistUrl,
+			GoProxyUrl:   *opts.RegistryGoUrl,
+			GitHubUrl:    *opts.VerifyGitHubUrl,
+			RdapUrl:      *opts.RegistryRdapUrl,
+		}))
+		explore.RemoveClaimedPackages(*opts.Owner, *opts.Repo, collectedResults)
 	}
-
-	if len(*opts.WordlistKeywords) > 0 {
-		logger.Print(*opts.Owner, *opts.Repo, "search-logs", "Searching logs for keywords")
-		collectedResults := explore.SearchLogsForKeywords(*opts.Owner, *opts.Repo, *opts.WordlistKeywords, *opts.ThreadsSearch)
-		logger.Print(*opts.Owner, *opts.Repo, "search-logs", "Finished search", len(collectedResults), "keywords found")
-		for matchedString, collectedResult := range collectedResults {
-			globalCollectedResults[matchedString] = collectedResult
-		}
-	} else {
-		logger.Print(*opts.Owner, *opts.Repo, "search-logs", "No keywords wordlist provided")
+	for matchedString, collectedResult := range collectedResults {
+		globalCollectedResults[matchedString] = collectedResult
 	}
 
 	return globalCollectedResults
@@ -182,18 +358,10 @@ func searchRepoLogs(opts Opts) map[string]explore.CollectedResult {
 func appendGlobalCollectedResults(globalCollectedResults, collectedResultsToAppend map[string]explore.CollectedResult) {
 	for matchedString, collectedResultToAppend := range collectedResultsToAppend {
 		if existingGlobalCollectedResult, exists := globalCollectedResults[matchedString]; exists {
-			if !existingGlobalCollectedResult.IsCondensed && !collectedResultToAppend.IsCondensed {
-				updatedGlobalCollectedResult := existingGlobalCollectedResult
-				updatedGlobalCollectedResult.Files += collectedResultToAppend.Files
-				updatedGlobalCollectedResult.Occurrences += collectedResultToAppend.Occurrences
-				globalCollectedResults[matchedString] = updatedGlobalCollectedResult
-			} else if existingGlobalCollectedResult.IsCondensed && !collectedResultToAppend.IsCondensed {
-				globalCollectedResults[matchedString] = collectedResultToAppend
-			} else if existingGlobalCollectedResult.IsCondensed && collectedResultToAppend.IsCondensed {
-				updatedGlobalCollectedResult := existingGlobalCollectedResult
-				updatedGlobalCollectedResult.Occurrences += collectedResultToAppend.Occurrences
-				globalCollectedResults[matchedString] = updatedGlobalCollectedResult
-			}
+			updatedGlobalCollectedResult := existingGlobalCollectedResult
+			updatedGlobalCollectedResult.Files += collectedResultToAppend.Files
+			updatedGlobalCollectedResult.Occurrences += collectedResultToAppend.Occurrences
+			globalCollectedResults[matchedString] = updatedGlobalCollectedResult
 		} else {
 			globalCollectedResults[matchedString] = collectedResultToAppend
 		}